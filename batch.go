@@ -0,0 +1,451 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// batchMode reports whether hands are read in batch mode: with -batch or
+// -input, or when stdin is not a terminal and -batch is not set to false.
+func batchMode() bool {
+	if *input != "" {
+		return true
+	}
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		set = set || f.Name == "batch"
+	})
+	if set {
+		return *batch
+	}
+	return !isTerminal(os.Stdin)
+}
+
+// puzzle is one hand of a batch: the request to solve, or why its input
+// is not one.
+type puzzle struct {
+	input string
+	req   solveRequest
+	err   error
+}
+
+// puzzleResult is the outcome of one puzzle, as written to an -output
+// file in JSON.
+type puzzleResult struct {
+	solveResponse
+	// Input is the line or row the puzzle was read from, when it was not
+	// a hand.
+	Input string `json:"input,omitempty"`
+	Error string `json:"error,omitempty"`
+	// err is the error behind Error, which the text format translates.
+	err error
+}
+
+// runBatch solves the puzzles of -input, or one hand per line of in,
+// skipping blank lines, across -workers goroutines. It writes one result
+// per puzzle, in input order, to -output or else out: a line with the
+// solutions and the first of them in the order of -sort, that there are
+// none, or why the puzzle cannot be solved as given; -format and the
+// extension of -output choose other formats, see resultWriter. There is no
+// banner and no prompt, and with -quiet no summary of an -output file. The
+// exit code is 2 if a puzzle could not be solved as given, e.g. a line that
+// is not a hand, and 0 otherwise. Ctrl-C stops the run, see solvePuzzles,
+// and writes how far it got to stderr with exit code 130.
+func runBatch(sess *session, opts []solver.Option, in io.Reader, out io.Writer) int {
+	var next func() (puzzle, bool)
+	readErr := func() error { return nil }
+	if *input != "" {
+		puzzles, err := readPuzzleFile(sess.slv, *input)
+		if err != nil {
+			errorf("%s", err)
+			return 2
+		}
+		next = func() (puzzle, bool) {
+			if len(puzzles) == 0 {
+				return puzzle{}, false
+			}
+			p := puzzles[0]
+			puzzles = puzzles[1:]
+			return p, true
+		}
+	} else {
+		next, readErr = readPuzzleLines(sess.slv, in)
+	}
+	// read counts the puzzles read so far, which the summaries report;
+	// next runs on the goroutine of solvePuzzles that starts the searches.
+	var read atomic.Int64
+	counted := func() (puzzle, bool) {
+		p, ok := next()
+		if ok {
+			read.Add(1)
+		}
+		return p, ok
+	}
+
+	toFile := *output != ""
+	if toFile {
+		f, err := os.Create(*output)
+		if err != nil {
+			errorf("%s", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+	write, finish := resultWriter(w, sess)
+	defer finish()
+
+	code, solvable, finished := 0, 0, 0
+	start := time.Now()
+	solvePuzzles(opts, counted, func(result puzzleResult) {
+		if result.Error != "" {
+			code = 2
+		} else if result.Count > 0 {
+			solvable++
+		}
+		finished++
+		write(result)
+	})
+	if isInterrupted() {
+		w.Flush()
+		fmt.Fprint(os.Stderr, sprintf("\nInterrupted after %s: %d of %d puzzle(s) done, %d of them solvable.\n",
+			time.Since(start).Round(time.Millisecond), finished, read.Load(), solvable))
+		return 130
+	}
+	if err := readErr(); err != nil {
+		w.Flush()
+		errorf("%s", err)
+		return 2
+	}
+	if toFile && !*quiet {
+		printf("Solved %d of %d puzzle(s); results are in %s\n", solvable, read.Load(), *output)
+	}
+	return code
+}
+
+// resultWriter returns the function runBatch writes each result to w with,
+// with the -output-template or in the -format asked for or, for text, the
+// one the extension of -output names, and the function that ends the
+// output. Each result is flushed as soon as it is written, so a long run
+// can be followed as it goes, except in a JSON array.
+func resultWriter(w *bufio.Writer, sess *session) (write func(puzzleResult), finish func()) {
+	if sess.tmpl != nil {
+		return templateWriter(w, sess)
+	}
+	kind := *format
+	if ext := strings.ToLower(filepath.Ext(*output)); kind == "text" && (ext == ".json" || ext == ".csv") {
+		kind = ext[1:]
+	}
+	finish = func() {}
+	switch kind {
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		write = func(result puzzleResult) {
+			enc.Encode(result)
+			w.Flush()
+		}
+	case "json":
+		var results []puzzleResult
+		write = func(result puzzleResult) { results = append(results, result) }
+		finish = func() { json.NewEncoder(w).Encode(results) }
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"numbers", "target", "solvable", "solution_count", "first_solution", "error"})
+		write = func(result puzzleResult) {
+			cw.Write(resultRow(sess, result))
+			cw.Flush()
+		}
+	case "markdown":
+		return markdownWriter(w, sess)
+	case "latex":
+		return latexWriter(w, sess)
+	default:
+		write = func(result puzzleResult) {
+			writeResultLine(w, sess, result)
+			w.Flush()
+		}
+	}
+	return write, finish
+}
+
+// resultRow is the CSV row of one result: the numbers separated by
+// spaces, the target, whether the hand is solvable, how many solutions it
+// has, the first of them in the order of -sort, and the error for a puzzle
+// that could not be solved, whose other fields are empty.
+func resultRow(sess *session, result puzzleResult) []string {
+	if result.Error != "" {
+		numbers := result.Input
+		if numbers == "" {
+			numbers = formatNumbers(result.Nums)
+		}
+		return []string{numbers, "", "", "", "", result.Error}
+	}
+	first := ""
+	if len(result.Solutions) > 0 {
+		solver.Sort(result.Solutions, sess.order)
+		first = displayFormula(result.Solutions[0].Formula)
+	}
+	return []string{formatNumbers(result.Nums), formatNumber(result.Target), strconv.FormatBool(result.Count > 0), strconv.Itoa(result.Count), first, ""}
+}
+
+// formatNumbers prints nums separated by spaces, e.g. "3 3 8 8".
+func formatNumbers(nums []float64) string {
+	parts := make([]string, len(nums))
+	for i, num := range nums {
+		parts[i] = formatNumber(num)
+	}
+	return strings.Join(parts, " ")
+}
+
+// solvePuzzles solves the puzzles next returns, until it reports false,
+// across -workers goroutines, each search on a single one, and passes every
+// result to emit in input order as soon as it and those before it are
+// done. Puzzles are read as the searches need them, at most a window of
+// twice -workers ahead of the first result not emitted, so results follow
+// a slow producer on stdin and memory does not grow with the input. Once
+// Ctrl-C is pressed no more puzzles are read or started, the searches
+// under way return what they have found, and the results up to the first
+// puzzle not started are emitted.
+func solvePuzzles(opts []solver.Option, next func() (puzzle, bool), emit func(puzzleResult)) {
+	n := *workers
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+	opts = append(opts, solver.WithWorkers(1))
+	type job struct {
+		p    puzzle
+		done chan puzzleResult
+	}
+	// pending holds the result channel of every puzzle read and not yet
+	// emitted, in input order. A channel is closed without a result for a
+	// puzzle that was never started.
+	pending := make(chan chan puzzleResult, 2*n)
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case j, ok := <-jobs:
+					if !ok {
+						return
+					}
+					j.done <- solvePuzzle(opts, j.p)
+				case <-interrupted.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		for interrupted.Err() == nil {
+			p, ok := next()
+			if !ok {
+				return
+			}
+			done := make(chan puzzleResult, 1)
+			select {
+			case pending <- done:
+			case <-interrupted.Done():
+				return
+			}
+			select {
+			case jobs <- job{p, done}:
+			case <-interrupted.Done():
+				close(done)
+				return
+			}
+		}
+	}()
+	// emitNext emits the oldest pending result, waiting for it if it is
+	// under way, and reports false once there is none to wait for.
+	emitNext := func(done chan puzzleResult) bool {
+		result, ok := <-done
+		if ok {
+			emit(result)
+		}
+		return ok
+	}
+	func() {
+		for {
+			select {
+			case done, ok := <-pending:
+				if !ok || !emitNext(done) {
+					return
+				}
+			case <-interrupted.Done():
+				// The reader may be waiting on its input; emit what was
+				// started without waiting for more.
+				for {
+					select {
+					case done, ok := <-pending:
+						if !ok || !emitNext(done) {
+							return
+						}
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+// solvePuzzle solves one puzzle as -json-request would.
+func solvePuzzle(opts []solver.Option, p puzzle) puzzleResult {
+	if p.err != nil {
+		return puzzleResult{Input: p.input, Error: p.err.Error(), err: p.err}
+	}
+	resp, err := answerRequest(interrupted, opts, p.req)
+	if err != nil {
+		return puzzleResult{solveResponse: solveResponse{Nums: p.req.Nums}, Error: err.Error(), err: err}
+	}
+	return puzzleResult{solveResponse: resp}
+}
+
+// writeResultLine writes the line of one result in the text format of
+// batch mode, e.g. "3, 3, 8, 8: 1 solution(s), first 8 / (3 - 8 / 3)".
+func writeResultLine(w io.Writer, sess *session, result puzzleResult) {
+	switch {
+	case result.Input != "":
+		fmt.Fprint(w, sprintf("%s: error: %s\n", result.Input, resultError(result)))
+		return
+	case result.Error != "":
+		fmt.Fprint(w, sprintf("%s: error: %s\n", formatHand(result.Nums), resultError(result)))
+		return
+	}
+	note := ""
+	if result.Truncated {
+		note = translate(" (search truncated)")
+	}
+	if result.Count == 0 {
+		fmt.Fprint(w, sprintf("%s: no solutions%s\n", formatHand(result.Nums), note))
+		return
+	}
+	solver.Sort(result.Solutions, sess.order)
+	fmt.Fprint(w, sprintf("%s: %d solution(s)%s, first %s\n", formatHand(result.Nums), result.Count, note, displayFormula(result.Solutions[0].Formula)))
+}
+
+// resultError returns the error of result, a puzzle that could not be
+// solved, in the language of -lang.
+func resultError(result puzzleResult) string {
+	if result.err != nil {
+		return errorMessage(result.err)
+	}
+	return result.Error
+}
+
+// readPuzzleLines returns a function that reads the next hand from the
+// lines of in, skipping blank lines, and reports false at the end of in.
+// err then returns why reading stopped, if not at the end.
+func readPuzzleLines(slv *solver.Solver, in io.Reader) (next func() (puzzle, bool), err func() error) {
+	scanner := bufio.NewScanner(in)
+	next = func() (puzzle, bool) {
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				return parsePuzzle(slv, line), true
+			}
+		}
+		return puzzle{}, false
+	}
+	return next, scanner.Err
+}
+
+// parsePuzzle reads the hand written in input.
+func parsePuzzle(slv *solver.Solver, input string) puzzle {
+	nums, err := slv.ParseHandN(input, *count)
+	return puzzle{input: input, req: solveRequest{Nums: nums}, err: err}
+}
+
+// readPuzzleFile reads the puzzles of an -input file. A .csv file holds
+// the numbers of one hand per row, e.g. 3,3,8,8, after an optional header
+// row; a .json file is an array of -json-request requests, e.g.
+// [{"nums":[3,3,8,8],"target":24}], so each may set its own target and
+// operators.
+func readPuzzleFile(slv *solver.Solver, path string) ([]puzzle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		r := csv.NewReader(f)
+		r.FieldsPerRecord = -1
+		rows, err := r.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		var puzzles []puzzle
+		for i, row := range rows {
+			p := parsePuzzle(slv, strings.Join(row, " "))
+			if i == 0 && p.err != nil && isHeader(row) {
+				continue
+			}
+			if !isBlank(row) {
+				puzzles = append(puzzles, p)
+			}
+		}
+		return puzzles, nil
+	case ".json":
+		var reqs []solveRequest
+		if err := json.NewDecoder(f).Decode(&reqs); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		puzzles := make([]puzzle, len(reqs))
+		for i, req := range reqs {
+			puzzles[i] = puzzle{req: req}
+			if len(req.Nums) == 0 {
+				puzzles[i] = puzzle{input: fmt.Sprintf("puzzle %d", i+1), err: errors.New("no numbers given")}
+			}
+		}
+		return puzzles, nil
+	}
+	return nil, newError("cannot read %s: -input takes a .csv or .json file", path)
+}
+
+// isHeader reports whether a first CSV row is a header rather than a bad
+// hand: none of its fields starts with a digit.
+func isHeader(row []string) bool {
+	for _, field := range row {
+		if first, _ := utf8.DecodeRuneInString(strings.TrimSpace(field)); unicode.IsDigit(first) {
+			return false
+		}
+	}
+	return true
+}
+
+// isBlank reports whether every field of a CSV row is empty.
+func isBlank(row []string) bool {
+	for _, field := range row {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"math/rand/v2"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// runBench handles the bench subcommand: it solves the standard hands, or
+// a random sample of them, and reports throughput and allocations. The
+// options of the main flags apply, except that the cache is left out so
+// every hand is searched.
+func runBench(opts []solver.Option, args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	sample := fs.Int("sample", 0, "solve `N` hands picked at random instead of all 1820 standard hands")
+	seed := fs.Uint64("seed", 1, "seed for -sample, so runs can be compared")
+	cpuProfile := fs.String("cpuprofile", "", "write a CPU profile to `FILE`")
+	memProfile := fs.String("memprofile", "", "write a heap profile to `FILE` after the run")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	hands := solver.StandardHands()
+	if *sample > 0 {
+		rng := rand.New(rand.NewPCG(*seed, 0))
+		picked := make([][]float64, *sample)
+		for i := range picked {
+			picked[i] = hands[rng.IntN(len(hands))]
+		}
+		hands = picked
+	}
+	slv := solver.New(append(opts, solver.WithCache(nil))...)
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			errorf("%s", err)
+			return 1
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			errorf("%s", err)
+			return 1
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	solvable, solutions := 0, 0
+	for _, hand := range hands {
+		found, err := slv.Solve(hand)
+		if err != nil {
+			errorf("%v: %s", hand, err)
+			return 1
+		}
+		if len(found) > 0 {
+			solvable++
+		}
+		solutions += len(found)
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	n := len(hands)
+	printf("Solved %d hand(s) in %s: %d solvable, %d solution(s)\n", n, elapsed.Round(time.Millisecond), solvable, solutions)
+	printf("%.0f hands/s, %s/hand\n", float64(n)/elapsed.Seconds(), (elapsed / time.Duration(n)).Round(time.Microsecond))
+	printf("%.0f allocs/hand, %.1f KB/hand\n",
+		float64(after.Mallocs-before.Mallocs)/float64(n), float64(after.TotalAlloc-before.TotalAlloc)/float64(n)/1024)
+
+	if *memProfile != "" {
+		f, err := os.Create(*memProfile)
+		if err != nil {
+			errorf("%s", err)
+			return 1
+		}
+		defer f.Close()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			errorf("%s", err)
+			return 1
+		}
+	}
+	return 0
+}
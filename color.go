@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ANSI escape sequences of the colors of the output.
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[31m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+)
+
+// colorful reports whether output is colored: when stdout is a terminal,
+// unless -no-color is set or NO_COLOR is set to anything but empty, see
+// https://no-color.org.
+var colorful = sync.OnceValue(func() bool {
+	return !*noColor && !*plain && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+})
+
+// colorfulErrors is colorful for stderr, which errors are written to.
+var colorfulErrors = sync.OnceValue(func() bool {
+	return !*noColor && !*plain && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stderr)
+})
+
+// paint wraps s in the escape sequence color when output is colored.
+func paint(s, color string) string {
+	if !colorful() {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// errorf prints an error message to stderr, so it stays out of results
+// piped from stdout: "Error: ", in red when stderr is colored, then format
+// formatted as sprintf does, and a newline.
+func errorf(format string, args ...any) {
+	label := translate("Error:")
+	if colorfulErrors() {
+		label = colorRed + label + colorReset
+	}
+	fmt.Fprintf(os.Stderr, "%s %s\n", label, sprintf(format, args...))
+}
+
+// colorOperators colors the operators of a formula as printed by
+// displayFormula. Like it, it only touches operators surrounded by spaces,
+// so signs of numbers are kept.
+func colorOperators(formula string) string {
+	if !colorful() {
+		return formula
+	}
+	for _, op := range []string{"+", "-", "*", "/", "^", "%", "×", "÷", "−"} {
+		formula = strings.ReplaceAll(formula, " "+op+" ", " "+paint(op, colorCyan)+" ")
+	}
+	for _, op := range []string{"sqrt", "√"} {
+		formula = strings.ReplaceAll(formula, op+"(", paint(op, colorCyan)+"(")
+	}
+	return strings.ReplaceAll(formula, "!", paint("!", colorCyan))
+}
+
+// colorSolution is the "formula = value" of a line of output, with the
+// operators and the value colored. highlighted puts it in bold as well,
+// for the most elegant solution of a list.
+func colorSolution(formula string, value float64, highlighted bool) string {
+	line := colorOperators(displayFormula(formula)) + " = " + paint(formatNumber(value), colorYellow)
+	if highlighted && colorful() {
+		// Bold is turned back on after every color inside the line ends.
+		line = colorBold + strings.ReplaceAll(line, colorReset, colorReset+colorBold) + colorReset
+	}
+	return line
+}
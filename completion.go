@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// fileFlags lists the flags whose value is a file name, which completion
+// completes as such.
+var fileFlags = []string{"cache", "config", "input", "output", "output-template"}
+
+// flagValues returns the values a flag takes when there is a fixed set of
+// them, for completion.
+func flagValues(name string) []string {
+	switch name {
+	case "format":
+		return formats
+	case "sort":
+		values := make([]string, len(solver.SortOrders))
+		for i, order := range solver.SortOrders {
+			values[i] = string(order)
+		}
+		return values
+	case "dedup":
+		values := make([]string, len(solver.DedupLevels))
+		for i, level := range solver.DedupLevels {
+			values[i] = string(level)
+		}
+		return values
+	case "lang":
+		values := make([]string, len(languages))
+		for i, tag := range languages {
+			values[i] = tag.String()
+		}
+		return values
+	}
+	return nil
+}
+
+// runCompletion handles the completion subcommand: it writes the script
+// that completes the commands and flags, and the values of -format and
+// -sort, in bash, zsh or fish, e.g. for ~/.bashrc:
+//
+//	source <(24Solver completion bash)
+func runCompletion(args []string) int {
+	fs := flag.NewFlagSet("completion", flag.ContinueOnError)
+	name := fs.String("name", "24Solver", "the name the program is run as")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	scripts := map[string]func(io.Writer, string){"bash": writeBash, "zsh": writeZsh, "fish": writeFish}
+	write, ok := scripts[fs.Arg(0)]
+	if fs.NArg() != 1 || !ok {
+		errorf("give the shell to complete for: completion bash, zsh or fish")
+		return 2
+	}
+	write(os.Stdout, *name)
+	return 0
+}
+
+// completionFlag is a flag of the main flag set as completion sees it.
+type completionFlag struct {
+	name, usage string
+	// takesValue is set unless the flag is a boolean one.
+	takesValue bool
+}
+
+// completionFlags lists the flags of the main flag set, in order of name.
+func completionFlags() []completionFlag {
+	var flags []completionFlag
+	flag.VisitAll(func(f *flag.Flag) {
+		_, usage := flag.UnquoteUsage(f)
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{name: f.Name, usage: shortUsage(usage), takesValue: !ok || !b.IsBoolFlag()})
+	})
+	return flags
+}
+
+// shortUsage cuts a flag's usage down to its first clause, to describe it
+// in a completion menu.
+func shortUsage(usage string) string {
+	for _, sep := range []string{", e.g.", "; ", " ("} {
+		usage, _, _ = strings.Cut(usage, sep)
+	}
+	return usage
+}
+
+// commandNames lists the names of the commands.
+func commandNames() []string {
+	names := make([]string, len(commands))
+	for i, command := range commands {
+		names[i], _, _ = strings.Cut(command[0], " ")
+	}
+	return names
+}
+
+// writeBash writes the bash completion of the program run as name.
+func writeBash(w io.Writer, name string) {
+	fn := "_" + strings.NewReplacer("-", "_", ".", "_").Replace(name)
+	var flags, valueFlags []string
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	// The flags can be given with one dash or two.
+	fmt.Fprintln(w, `	local flag="${prev#-}"`)
+	fmt.Fprintln(w, `	case "${flag#-}" in`)
+	for _, f := range completionFlags() {
+		flags = append(flags, "-"+f.name)
+		switch {
+		case flagValues(f.name) != nil:
+			fmt.Fprintf(w, "	%s)\n		COMPREPLY=($(compgen -W %q -- \"$cur\"))\n		return ;;\n", f.name, strings.Join(flagValues(f.name), " "))
+		case slices.Contains(fileFlags, f.name):
+			fmt.Fprintf(w, "	%s)\n		COMPREPLY=($(compgen -f -- \"$cur\"))\n		return ;;\n", f.name)
+		case f.takesValue:
+			valueFlags = append(valueFlags, f.name)
+		}
+	}
+	fmt.Fprintf(w, "	%s)\n		return ;;\n", strings.Join(valueFlags, "|"))
+	fmt.Fprintln(w, "	esac")
+	fmt.Fprintf(w, "	if [[ $cur == -* ]]; then\n		COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(flags, " "))
+	fmt.Fprintf(w, "	else\n		COMPREPLY=($(compgen -W %q -- \"$cur\"))\n	fi\n}\n", strings.Join(commandNames(), " "))
+	fmt.Fprintf(w, "complete -F %s %s\n", fn, name)
+}
+
+// writeZsh writes the zsh completion of the program run as name.
+func writeZsh(w io.Writer, name string) {
+	quote := strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace
+	fmt.Fprintf(w, "#compdef %s\n\n_arguments \\\n", name)
+	for _, f := range completionFlags() {
+		spec := "-" + f.name + "[" + quote(f.usage) + "]"
+		switch {
+		case flagValues(f.name) != nil:
+			spec += ":" + f.name + ":(" + strings.Join(flagValues(f.name), " ") + ")"
+		case slices.Contains(fileFlags, f.name):
+			spec += ":file:_files"
+		case f.takesValue:
+			spec += ":" + f.name + ":"
+		}
+		fmt.Fprintf(w, "  '%s' \\\n", spec)
+	}
+	var described []string
+	for _, command := range commands {
+		name, _, _ := strings.Cut(command[0], " ")
+		described = append(described, name+`\:"`+strings.ReplaceAll(quote(command[1]), `"`, `\"`)+`"`)
+	}
+	fmt.Fprintf(w, "  '1:command:((%s))' \\\n  '*::argument:_default'\n", strings.Join(described, " "))
+}
+
+// writeFish writes the fish completion of the program run as name.
+func writeFish(w io.Writer, name string) {
+	quote := strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace
+	names := commandNames()
+	for i, command := range commands {
+		fmt.Fprintf(w, "complete -c %s -n __fish_use_subcommand -f -a %s -d '%s'\n", name, names[i], quote(command[1]))
+	}
+	for _, f := range completionFlags() {
+		line := fmt.Sprintf("complete -c %s -o %s -d '%s'", name, f.name, quote(f.usage))
+		switch {
+		case flagValues(f.name) != nil:
+			line += fmt.Sprintf(" -x -a '%s'", strings.Join(flagValues(f.name), " "))
+		case slices.Contains(fileFlags, f.name):
+			line += " -r -F"
+		case f.takesValue:
+			line += " -x"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
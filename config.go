@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// defaultConfigFile is where the config file is read from without
+// -config: 24solver/config.toml in the user's config directory, e.g.
+// ~/.config/24solver/config.toml. It is empty when there is no such
+// directory.
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "24solver", "config.toml")
+}
+
+// loadSettings sets the flags that the command line does not from the
+// environment, see loadEnv, and then the flags neither sets from the
+// -config file, or the default one when it exists.
+func loadSettings() error {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if err := loadEnv(set); err != nil {
+		return err
+	}
+	if *configFile != "" {
+		return loadConfig(*configFile, true, set)
+	}
+	if path := defaultConfigFile(); path != "" {
+		return loadConfig(path, false, set)
+	}
+	return nil
+}
+
+// envPrefix starts the names of the environment variables that set flags.
+const envPrefix = "SOLVER24_"
+
+// loadEnv sets every flag that is not in set from the environment variable
+// named after it, e.g. SOLVER24_TARGET for -target and SOLVER24_NO_COLOR
+// for -no-color, and adds it to set. Variables set to nothing are
+// ignored, and those named after no flag too, with a warning on stderr.
+func loadEnv(set map[string]bool) error {
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		rest, ok := strings.CutPrefix(key, envPrefix)
+		if !ok || value == "" {
+			continue
+		}
+		name := strings.ReplaceAll(strings.ToLower(rest), "_", "-")
+		if flag.Lookup(name) == nil {
+			// An old or unrelated variable must not break every run.
+			fmt.Fprint(os.Stderr, sprintf("Warning: ignoring %s, which is not a setting; the variables are named like the flags, e.g. %sTARGET\n", key, envPrefix))
+			continue
+		}
+		if set[name] {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return newError("invalid %s %q: %v", key, value, err)
+		}
+		set[name] = true
+	}
+	return nil
+}
+
+// loadConfig reads the defaults of the flags from the TOML file at path,
+// one key per flag named as on the command line, e.g.
+//
+//	target = 36
+//	ops = "+-*"
+//	format = "markdown"
+//	no-color = true
+//
+// and sets every flag that is not in set. A list such as
+// target = [24, 36] is set as its values separated by commas. When
+// required is not set, a missing file is not an error.
+func loadConfig(path string, required bool, set map[string]bool) error {
+	var config map[string]any
+	if _, err := toml.DecodeFile(path, &config); err != nil {
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && !required:
+			return nil
+		case errors.Is(err, fs.ErrNotExist):
+			return err
+		}
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(config)) {
+		if flag.Lookup(name) == nil || name == "config" {
+			return newError("%s: unknown setting %q; settings are named like the flags, e.g. target", path, name)
+		}
+		if set[name] {
+			continue
+		}
+		value, err := configValue(config[name])
+		if err != nil {
+			return newError("%s: invalid %s: %v", path, name, err)
+		}
+		if err := flag.Set(name, value); err != nil {
+			return newError("%s: invalid %s %q: %v", path, name, value, err)
+		}
+	}
+	return nil
+}
+
+// configValue is the value of a flag as a setting of the config file
+// gives it, for flag.Set.
+func configValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case []any:
+		values := make([]string, len(v))
+		for i, item := range v {
+			value, err := configValue(item)
+			if err != nil {
+				return "", err
+			}
+			values[i] = value
+		}
+		return strings.Join(values, ","), nil
+	}
+	return "", newError("want a string, number, boolean or list, not %T", v)
+}
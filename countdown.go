@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// The numbers of the Countdown numbers game: two of each small number and
+// one of each large one.
+var (
+	countdownSmall = []float64{1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10}
+	countdownLarge = []float64{25, 50, 75, 100}
+)
+
+// countdownNumbers is how many numbers a Countdown round draws.
+const countdownNumbers = 6
+
+// runCountdown handles the countdown subcommand: a round of the Countdown
+// numbers game, with six numbers given as arguments or drawn at random
+// and a target from 100 to 999. Solutions may use any two or more of the
+// numbers, and every intermediate value must be a whole number of at
+// least 0. When the target cannot be reached, the nearest miss is shown.
+// The operators and output flags of the main flags apply.
+func runCountdown(opts []solver.Option, args []string) int {
+	fs := flag.NewFlagSet("countdown", flag.ContinueOnError)
+	target := fs.Int("target", 0, "the target, from 100 to 999 (0 picks one at random)")
+	large := fs.Int("large", 2, "how many of the `N` drawn numbers are large ones, from 0 to 4")
+	seed := fs.Uint64("seed", 0, "seed for the draw, so a round can be replayed (0 picks one)")
+	shown := fs.Int("show", 5, "show at most `N` solutions")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	rng := newRand(*seed)
+	var nums []float64
+	if fs.NArg() > 0 {
+		var err error
+		if nums, err = parseCountdownNumbers(fs.Args()); err != nil {
+			errorf("%s", err)
+			return 2
+		}
+	} else {
+		if *large < 0 || *large > len(countdownLarge) {
+			errorf("-large must be from 0 to %d, not %d", len(countdownLarge), *large)
+			return 2
+		}
+		nums = drawCountdown(rng, *large)
+	}
+	if *target == 0 {
+		*target = 100 + rng.IntN(900)
+	}
+	if *target < 100 || *target > 999 {
+		errorf("the target must be from 100 to 999, not %d", *target)
+		return 2
+	}
+
+	slv := solver.New(append(opts,
+		solver.WithTarget(float64(*target)),
+		solver.WithLargeNumbers(true),
+		solver.WithIntegerDivision(true),
+		solver.WithNonNegative(true),
+		solver.WithSubsets(true),
+	)...)
+	printf("Numbers: %s\n", formatHand(nums))
+	printf("Target: %d\n", *target)
+	fmt.Println("===============================")
+
+	found, err := slv.Solve(nums)
+	if err != nil {
+		errorf("%s", err)
+		return 1
+	}
+	if len(found) > 0 {
+		printf("Found %d solution(s):\n\n", len(found))
+		printCountdown(found, *shown)
+		return 0
+	}
+
+	best, distance, err := slv.Closest(nums)
+	if err != nil {
+		errorf("%s", err)
+		return 1
+	}
+	if len(best) == 0 {
+		printf("No value can be made from these numbers.\n")
+		return 0
+	}
+	printf("The target cannot be reached; the closest is %s away:\n\n", formatNumber(distance))
+	printCountdown(best, *shown)
+	return 0
+}
+
+// parseCountdownNumbers parses the six numbers of a round, each of which
+// must be one Countdown has, with small numbers at most twice.
+func parseCountdownNumbers(args []string) ([]float64, error) {
+	nums, err := solver.New(solver.WithLargeNumbers(true)).ParseHandN(strings.Join(args, " "), countdownNumbers)
+	if err != nil {
+		return nil, err
+	}
+	left := slices.Concat(countdownSmall, countdownLarge)
+	for _, num := range nums {
+		i := slices.Index(left, num)
+		if i < 0 {
+			return nil, newError("%s is not a Countdown number, or appears too often: use 1-10 at most twice and 25, 50, 75, 100 once", formatNumber(num))
+		}
+		left = slices.Delete(left, i, i+1)
+	}
+	return nums, nil
+}
+
+// drawCountdown draws large numbers from the large ones and the rest from
+// the small ones, without replacement.
+func drawCountdown(rng *rand.Rand, large int) []float64 {
+	draw := func(from []float64, n int) []float64 {
+		picked := slices.Clone(from)
+		rng.Shuffle(len(picked), func(i, j int) { picked[i], picked[j] = picked[j], picked[i] })
+		return picked[:n]
+	}
+	return slices.Concat(draw(countdownLarge, large), draw(countdownSmall, countdownNumbers-large))
+}
+
+// printCountdown prints at most limit solutions, all of them if limit is
+// zero or less, with how many numbers each uses.
+func printCountdown(solutions []solver.Solution, limit int) {
+	for i, solution := range solutions {
+		if limit > 0 && i == limit {
+			printf("... and %d more\n", len(solutions)-limit)
+			break
+		}
+		printf("%d. %s = %s (%d number(s))\n", i+1, displayFormula(solution.Formula), formatNumber(solution.Value), solution.Meta.Numbers)
+	}
+}
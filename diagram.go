@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// writeDiagrams writes the tree of every solution printed for nums to the
+// -dot directory as a Graphviz file, e.g. 3_3_8_8-24-1.dot for the first
+// solution making 24, which -dot-render has dot render too, and to the
+// -svg directory as an SVG image, e.g. 3_3_8_8-24-1.svg.
+func writeDiagrams(nums []float64, solutions []solver.Solution) error {
+	if *dotDir != "" {
+		err := writeDiagramFiles(*dotDir, ".dot", nums, solutions, func(path string, solution solver.Solution) error {
+			if err := os.WriteFile(path, []byte(solution.Tree.DOT(diagramLabel(solution))), 0o644); err != nil {
+				return err
+			}
+			if *dotRender == "" {
+				return nil
+			}
+			return renderDOT(path, *dotRender)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if *svgDir != "" {
+		return writeDiagramFiles(*svgDir, ".svg", nums, solutions, func(path string, solution solver.Solution) error {
+			return os.WriteFile(path, []byte(solution.Tree.SVG(diagramLabel(solution))), 0o644)
+		})
+	}
+	return nil
+}
+
+// writeDiagramFiles calls write with the path in dir of the file with ext
+// of every solution, creating dir if need be, and says how many were
+// written unless -quiet is set.
+func writeDiagramFiles(dir, ext string, nums []float64, solutions []solver.Solution, write func(path string, solution solver.Solution) error) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	hand := strings.ReplaceAll(formatNumbers(nums), " ", "_")
+	for i, solution := range solutions {
+		path := filepath.Join(dir, fmt.Sprintf("%s-%s-%d%s", hand, formatNumber(solution.Value), i+1, ext))
+		if err := write(path, solution); err != nil {
+			return err
+		}
+	}
+	if !*quiet {
+		printf("Wrote %d diagram(s) to %s\n", len(solutions), dir)
+	}
+	return nil
+}
+
+// diagramLabel is the caption of the diagram of a solution: its formula
+// and value.
+func diagramLabel(solution solver.Solution) string {
+	return displayFormula(solution.Formula) + " = " + formatNumber(solution.Value)
+}
+
+// renderDOT runs Graphviz on the DOT file at path to render it in format,
+// e.g. png or svg, next to it.
+func renderDOT(path, format string) error {
+	out := strings.TrimSuffix(path, ".dot") + "." + format
+	cmd := exec.Command("dot", "-T"+format, "-o", out, path)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return errors.New("cannot render the diagrams: dot was not found; install Graphviz or leave out -dot-render")
+		}
+		return fmt.Errorf("dot -T%s %s: %v: %s", format, path, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
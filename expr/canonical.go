@@ -0,0 +1,372 @@
+package expr
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// collectOperands traverses chains of the same associative operator (like a + b + c)
+// to flatten the structure for normalization.
+func collectOperands(node *Node, op string, operands *[]string) {
+	// If the child node is part of the same associative chain, recurse.
+	if node.Op == op {
+		if node.Left != nil {
+			collectOperands(node.Left, op, operands)
+		}
+		if node.Right != nil {
+			collectOperands(node.Right, op, operands)
+		}
+	} else {
+		// Otherwise, it's a new sub-expression, get its key.
+		*operands = append(*operands, canonicalKey(node))
+	}
+}
+
+// CanonicalKey generates a unique, normalized string representation from an expression tree.
+// This key ignores differences in operator order (commutativity) and grouping (associativity),
+// so two trees that only differ in those ways get the same key.
+//
+// The key is built bottom-up by these rules, and the format is stable: keys
+// stored by one version compare equal to keys computed by a later one.
+//
+//  1. A leaf is its value in the shortest form that round-trips, as by
+//     strconv.FormatFloat(v, 'g', -1, 64): "8", "2.5", "1e+21".
+//  2. x*1, 1*x, x/1 and x^1 take the key of x when the 1 is a direct child
+//     whose key is "1". This is checked before flattening, so the 1 in a
+//     longer chain such as (2*1)*3 is kept: "(1*2*3)".
+//  3. A chain of + (or of *) is flattened, its operand keys are sorted
+//     bytewise and joined with the operator in parentheses: "(1+2+3)".
+//  4. -, /, ^ and % keep their operand order: "(" + left + op + right + ")",
+//     e.g. "(8/(3-(8/3)))".
+//  5. A unary operation is its operator and its operand's key in
+//     parentheses: "sqrt(9)", "!(4)".
+//  6. Before any of this, negations are moved as far out of the tree as
+//     they go past + - * and /: -a + b is keyed as b - a, a - -b as a + b
+//     and -a * b as -(a * b). A negation that reaches a subtraction
+//     reverses it instead, so -(a - b) is keyed as b - a and -(a * (b - c))
+//     as a * (c - b). A negation that is left follows rule 5: "neg(4)".
+//
+// Internal node values are ignored; only the operators and leaves matter.
+func CanonicalKey(node *Node) string {
+	return canonicalKey(denegate(node))
+}
+
+func canonicalKey(node *Node) string {
+	// Base case: leaf node (a number)
+	if node.IsLeaf() {
+		return formatNumber(node.Value)
+	}
+
+	if node.IsUnary() {
+		return node.Op + "(" + canonicalKey(node.Left) + ")"
+	}
+
+	// Recursive step: get keys for children
+	keyL := canonicalKey(node.Left)
+	keyR := canonicalKey(node.Right)
+
+	// --- Normalization Rules ---
+
+	// 1. Identity operations: simplify expressions with *1, /1 or ^1.
+	if node.Op == "*" {
+		if keyL == "1" {
+			return keyR
+		}
+		if keyR == "1" {
+			return keyL
+		}
+	}
+	if (node.Op == "/" || node.Op == "^") && keyR == "1" {
+		return keyL
+	}
+
+	// 2. Associativity & Commutativity: for + and *, flatten the expression,
+	// sort the operands, and join them. This treats (a+b)+c and c+(a+b) as identical.
+	if node.Op == "+" || node.Op == "*" {
+		operands := []string{}
+		collectOperands(node, node.Op, &operands)
+		sort.Strings(operands) // Sort for commutativity.
+		return "(" + strings.Join(operands, node.Op) + ")"
+	}
+
+	// 3. For non-commutative/associative operations (-, /, ^, %), the order matters.
+	return "(" + keyL + node.Op + keyR + ")"
+}
+
+// signedKey marks a key as negated so that mirror keys can carry sign flips.
+func signedKey(key string, neg bool) string {
+	if neg {
+		return "~" + key
+	}
+	return key
+}
+
+// collectMirrorOperands is the mirrorKey counterpart of collectOperands.
+// It flattens a chain of + or * and returns whether an odd number of the
+// collected factors were negated (only meaningful for *).
+func collectMirrorOperands(node *Node, op string, operands *[]string) bool {
+	if node.Op == op {
+		negL := collectMirrorOperands(node.Left, op, operands)
+		negR := collectMirrorOperands(node.Right, op, operands)
+		return negL != negR
+	}
+	key, neg := mirrorKey(node)
+	if op == "+" {
+		*operands = append(*operands, signedKey(key, neg))
+		return false
+	}
+	*operands = append(*operands, key)
+	return neg
+}
+
+// mirrorKey is a looser variant of CanonicalKey that also treats a
+// subtraction and its reversal as the same value with the sign flipped.
+// Sign flips cancel pairwise inside products and quotients, so
+// (a-b)*(c-d) and (b-a)*(d-c) end up with the same key. The returned bool
+// reports whether the key stands for the negation of the node's value.
+func mirrorKey(node *Node) (string, bool) {
+	// Base case: leaf node (a number)
+	if node.IsLeaf() {
+		return formatNumber(node.Value), false
+	}
+
+	if node.IsUnary() {
+		if node.Op == "neg" {
+			key, neg := mirrorKey(node.Left)
+			return key, !neg
+		}
+		return node.Op + "(" + signedKey(mirrorKey(node.Left)) + ")", false
+	}
+
+	switch node.Op {
+	case "+", "*":
+		operands := []string{}
+		neg := collectMirrorOperands(node, node.Op, &operands)
+		sort.Strings(operands)
+		return "(" + strings.Join(operands, node.Op) + ")", neg
+	case "-":
+		keyL := signedKey(mirrorKey(node.Left))
+		keyR := signedKey(mirrorKey(node.Right))
+		// Order the operands so a-b and b-a share a key, remembering the flip.
+		if keyL > keyR {
+			return "(" + keyR + "-" + keyL + ")", true
+		}
+		return "(" + keyL + "-" + keyR + ")", false
+	case "^":
+		// A sign flip does not carry through a power, so each side is
+		// keyed with its own sign.
+		keyL := signedKey(mirrorKey(node.Left))
+		keyR := signedKey(mirrorKey(node.Right))
+		return "(" + keyL + "^" + keyR + ")", false
+	case "%":
+		// A remainder has the sign of its left operand alone.
+		keyL, negL := mirrorKey(node.Left)
+		keyR, _ := mirrorKey(node.Right)
+		return "(" + keyL + "%" + keyR + ")", negL
+	default:
+		keyL, negL := mirrorKey(node.Left)
+		keyR, negR := mirrorKey(node.Right)
+		return "(" + keyL + node.Op + keyR + ")", negL != negR
+	}
+}
+
+// MirrorKey is like CanonicalKey but also treats a subtraction and its
+// reversal as the same value with the sign flipped. Sign flips cancel in
+// pairs inside products and quotients, so (a-b)*(c-d) and (b-a)*(d-c) share
+// a key. A key that stands for the negated value starts with "~". The keys
+// are not interchangeable with CanonicalKey keys.
+func MirrorKey(node *Node) string {
+	return signedKey(mirrorKey(denegate(node)))
+}
+
+// denegate returns node with its negations moved as far out as they go,
+// for rule 6 of CanonicalKey. A tree without negations is returned as is.
+func denegate(node *Node) *Node {
+	if !hasNegation(node) {
+		return node
+	}
+	return negated(pushNegation(node))
+}
+
+// hasNegation reports whether the tree negates anything.
+func hasNegation(node *Node) bool {
+	switch {
+	case node.IsLeaf():
+		return false
+	case node.IsUnary():
+		return node.Op == "neg" || hasNegation(node.Left)
+	}
+	return hasNegation(node.Left) || hasNegation(node.Right)
+}
+
+// pushNegation returns a copy of the tree with no negations other than
+// under ^ and the unary operators, and whether it stands for the negation
+// of node.
+func pushNegation(node *Node) (*Node, bool) {
+	if node.IsLeaf() {
+		return node, false
+	}
+	if node.IsUnary() {
+		x, neg := pushNegation(node.Left)
+		if node.Op == "neg" {
+			return x, !neg
+		}
+		return &Node{Op: node.Op, Left: negated(x, neg)}, false
+	}
+	a, negA := pushNegation(node.Left)
+	b, negB := pushNegation(node.Right)
+	op := func(op string, left, right *Node) *Node {
+		return &Node{Op: op, Left: left, Right: right}
+	}
+	switch node.Op {
+	case "+":
+		switch {
+		case negA == negB:
+			return op("+", a, b), negA // -a + -b = -(a + b)
+		case negA:
+			return op("-", b, a), false // -a + b = b - a
+		}
+		return op("-", a, b), false // a + -b = a - b
+	case "-":
+		switch {
+		case negA && negB:
+			return op("-", b, a), false // -a - -b = b - a
+		case negA:
+			return op("+", a, b), true // -a - b = -(a + b)
+		case negB:
+			return op("+", a, b), false // a - -b = a + b
+		}
+		return op("-", a, b), false
+	case "*", "/":
+		return op(node.Op, a, b), negA != negB
+	}
+	return op(node.Op, negated(a, negA), negated(b, negB)), false
+}
+
+// negated returns node, or its negation when neg is set, absorbed into a
+// subtraction when there is one to absorb it, see absorb.
+func negated(node *Node, neg bool) *Node {
+	if !neg {
+		return node
+	}
+	if n, ok := absorb(node); ok {
+		return n
+	}
+	return &Node{Op: "neg", Left: node}
+}
+
+// absorb returns the negation of node without a negation, if it holds a
+// subtraction that can be reversed instead: a - b becomes b - a, a
+// product or quotient has its first such factor negated, and a sum a + b
+// becomes -a - b with -a absorbed, or -b - a.
+func absorb(node *Node) (*Node, bool) {
+	if node.IsLeaf() || node.IsUnary() {
+		return nil, false
+	}
+	switch node.Op {
+	case "-":
+		return &Node{Op: "-", Left: node.Right, Right: node.Left}, true
+	case "*", "/":
+		if l, ok := absorb(node.Left); ok {
+			return &Node{Op: node.Op, Left: l, Right: node.Right}, true
+		}
+		if r, ok := absorb(node.Right); ok {
+			return &Node{Op: node.Op, Left: node.Left, Right: r}, true
+		}
+	case "+":
+		if l, ok := absorb(node.Left); ok {
+			return &Node{Op: "-", Left: l, Right: node.Right}, true
+		}
+		if r, ok := absorb(node.Right); ok {
+			return &Node{Op: "-", Left: r, Right: node.Left}, true
+		}
+	}
+	return nil, false
+}
+
+// looseKey builds the key of LooseKey for a tree whose negations have been
+// moved out by denegate.
+func looseKey(node *Node) string {
+	if node.IsLeaf() {
+		return formatNumber(node.Value)
+	}
+	if node.IsUnary() {
+		return node.Op + "(" + looseKey(node.Left) + ")"
+	}
+	switch node.Op {
+	case "+", "-":
+		var plus, minus []string
+		collectTerms(node, &plus, &minus)
+		return chainKey(plus, minus, "+", "-", "0")
+	case "*", "/":
+		var times, over []string
+		collectTerms(node, &times, &over)
+		times = slices.DeleteFunc(times, func(key string) bool { return key == "1" })
+		over = slices.DeleteFunc(over, func(key string) bool { return key == "1" })
+		return chainKey(times, over, "*", "/", "1")
+	}
+	keyL, keyR := looseKey(node.Left), looseKey(node.Right)
+	if node.Op == "^" && keyR == "1" {
+		return keyL
+	}
+	return "(" + keyL + node.Op + keyR + ")"
+}
+
+// collectTerms flattens a chain of + and - (or of * and /) into the keys
+// of the terms it adds and those it subtracts (or the factors it
+// multiplies and those it divides by).
+func collectTerms(node *Node, direct, inverse *[]string) {
+	add, sub := "+", "-"
+	if node.Op == "*" || node.Op == "/" {
+		add, sub = "*", "/"
+	}
+	var walk func(n *Node, inverted bool)
+	walk = func(n *Node, inverted bool) {
+		switch n.Op {
+		case add:
+			walk(n.Left, inverted)
+			walk(n.Right, inverted)
+		case sub:
+			walk(n.Left, inverted)
+			walk(n.Right, !inverted)
+		default:
+			if inverted {
+				*inverse = append(*inverse, looseKey(n))
+			} else {
+				*direct = append(*direct, looseKey(n))
+			}
+		}
+	}
+	walk(node, false)
+}
+
+// chainKey joins the sorted keys of a flattened chain: the direct ones
+// with op and each inverse one after inv, e.g. "(a+c-b)". A chain with no
+// direct keys starts with identity; one with a single direct key and no
+// inverse ones is that key.
+func chainKey(direct, inverse []string, op, inv, identity string) string {
+	slices.Sort(direct)
+	slices.Sort(inverse)
+	if len(direct) == 0 {
+		direct = []string{identity}
+	}
+	if len(direct) == 1 && len(inverse) == 0 {
+		return direct[0]
+	}
+	key := "(" + strings.Join(direct, op)
+	for _, k := range inverse {
+		key += inv + k
+	}
+	return key + ")"
+}
+
+// LooseKey is a looser variant of CanonicalKey for the most aggressive
+// deduplication: it also flattens chains that mix + with - and * with /,
+// so a-b+c and a+c-b share a key, as do a/(b/c) and a*c/b, and it drops
+// every factor of 1 rather than only those of rule 2. Double negations
+// cancel, as in CanonicalKey. The keys are not interchangeable with
+// CanonicalKey keys.
+func LooseKey(node *Node) string {
+	return looseKey(denegate(node))
+}
@@ -0,0 +1,69 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/x0root/24Solver/expr"
+)
+
+// The keys of CanonicalKey are stored by callers, so its format must not
+// change: these are golden values, one or more per rule of its doc.
+func TestCanonicalKeyGolden(t *testing.T) {
+	tests := []struct {
+		input, key string
+	}{
+		{"8/(3-8/3)", "(8/(3-(8/3)))"},
+		{"2.5+1", "(1+2.5)"},
+		{"2*1", "2"},
+		{"1*2", "2"},
+		{"(2*1)*3", "(1*2*3)"},
+		{"(1+2+3)*4", "((1+2+3)*4)"},
+		{"4*(3+(2+1))", "((1+2+3)*4)"},
+		{"(3-1)*(13-1)", "((13-1)*(3-1))"},
+		{"(1-3)*(1-13)", "((1-13)*(1-3))"},
+		{"2^3*3", "((2^3)*3)"},
+		{"10%4+22", "((10%4)+22)"},
+		{"sqrt(9)*(4!)", "(!(4)*sqrt(9))"},
+		{"-(4)", "neg(4)"},
+		{"-(1-2)*3*8", "((2-1)*3*8)"},
+		{"-3+5", "(-3+5)"},
+		{"5- -3", "(5--3)"},
+	}
+	for _, tt := range tests {
+		tree, err := expr.Parse(tt.input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.input, err)
+		}
+		if key := expr.CanonicalKey(tree); key != tt.key {
+			t.Errorf("CanonicalKey(%s) = %q, want %q", tt.input, key, tt.key)
+		}
+	}
+}
+
+func TestCanonicalKeyEquivalence(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"1+2+3+4", "4+(3+(2+1))", true},
+		{"(1+2)*(3+4)", "(4+3)*(2+1)", true},
+		{"8/(3-8/3)", "8/(3-(8/3))", true},
+		{"-(1-2)*3*8", "(2-1)*3*8", true},
+		{"6-2", "2-6", false},
+		{"8/2", "2/8", false},
+		{"(1+2)*3", "1+2*3", false},
+	}
+	for _, tt := range tests {
+		a, err := expr.Parse(tt.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := expr.Parse(tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if same := expr.CanonicalKey(a) == expr.CanonicalKey(b); same != tt.same {
+			t.Errorf("CanonicalKey(%s) == CanonicalKey(%s) is %t, want %t", tt.a, tt.b, same, tt.same)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrDivisionByZero is returned by Eval when a tree divides by zero.
+	ErrDivisionByZero = errors.New("division by zero")
+	// ErrUndefinedPower is returned by Eval for a power it does not
+	// define: one whose exponent is not a whole number, 0^0, or 0 to a
+	// negative power.
+	ErrUndefinedPower = errors.New("undefined power")
+	// ErrUndefinedRemainder is returned by Eval for a remainder of
+	// numbers that are not whole.
+	ErrUndefinedRemainder = errors.New("remainder of a number that is not whole")
+	// ErrNegativeRoot is returned by Eval for the square root of a
+	// negative number.
+	ErrNegativeRoot = errors.New("square root of a negative number")
+	// ErrUndefinedFactorial is returned by Eval for the factorial of a
+	// number that is not a whole number from 0 to 170, the largest whose
+	// factorial is a finite float64.
+	ErrUndefinedFactorial = errors.New("factorial of a number that is not a whole number from 0 to 170")
+)
+
+// Eval computes the value of the tree from its leaves, ignoring the values
+// stored on internal nodes. Divisors closer to zero than 1e-9 count as
+// zero, matching the solver.
+func (n *Node) Eval() (float64, error) {
+	if n.IsLeaf() {
+		return n.Value, nil
+	}
+	l, err := n.Left.Eval()
+	if err != nil {
+		return 0, err
+	}
+	if n.IsUnary() {
+		return evalUnary(n.Op, l)
+	}
+	r, err := n.Right.Eval()
+	if err != nil {
+		return 0, err
+	}
+	switch n.Op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if math.Abs(r) < 1e-9 {
+			return 0, ErrDivisionByZero
+		}
+		return l / r, nil
+	case "^":
+		if r != math.Trunc(r) || l == 0 && r <= 0 {
+			return 0, ErrUndefinedPower
+		}
+		return math.Pow(l, r), nil
+	case "%":
+		if r == 0 {
+			return 0, ErrDivisionByZero
+		}
+		if l != math.Trunc(l) || r != math.Trunc(r) {
+			return 0, ErrUndefinedRemainder
+		}
+		return math.Mod(l, r), nil
+	}
+	return 0, fmt.Errorf("unknown operator '%s'", n.Op)
+}
+
+// evalUnary applies the unary operator op to x.
+func evalUnary(op string, x float64) (float64, error) {
+	switch op {
+	case "sqrt":
+		if x < 0 {
+			return 0, ErrNegativeRoot
+		}
+		return math.Sqrt(x), nil
+	case "!":
+		if x != math.Trunc(x) || x < 0 || x > 170 {
+			return 0, ErrUndefinedFactorial
+		}
+		result := 1.0
+		for k := 2.0; k <= x; k++ {
+			result *= k
+		}
+		return result, nil
+	case "neg":
+		return -x, nil
+	}
+	return 0, fmt.Errorf("unknown operator '%s'", op)
+}
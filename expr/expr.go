@@ -0,0 +1,42 @@
+// Package expr holds the expression trees produced by the solver, with
+// helpers to evaluate them and render them in infix, prefix (Polish) and
+// postfix (reverse Polish) notation.
+package expr
+
+import "strconv"
+
+// Node represents a node in an expression tree.
+// It can be a leaf (a number) or an internal node (an operation). A unary
+// operation, sqrt, ! (factorial) or neg (negation), has its operand in
+// Left and no Right.
+// In JSON a leaf is {"value":3} and an operation adds "op", "left" and
+// "right".
+type Node struct {
+	Op    string  `json:"op,omitempty"` // +, -, *, /, ^, %, sqrt, ! or neg; empty for a leaf
+	Value float64 `json:"value"`        // the number for a leaf, the result of Op otherwise
+	Left  *Node   `json:"left,omitempty"`
+	Right *Node   `json:"right,omitempty"`
+}
+
+// IsLeaf reports whether the node is a number rather than an operation.
+func (n *Node) IsLeaf() bool {
+	return n.Left == nil && n.Right == nil
+}
+
+// IsUnary reports whether the node is an operation on a single operand.
+func (n *Node) IsUnary() bool {
+	return n.Left != nil && n.Right == nil
+}
+
+// Clone returns a deep copy of the tree rooted at n.
+func (n *Node) Clone() *Node {
+	if n == nil {
+		return nil
+	}
+	return &Node{Op: n.Op, Value: n.Value, Left: n.Left.Clone(), Right: n.Right.Clone()}
+}
+
+// formatNumber renders a leaf value in its shortest form, e.g. 8 or 2.5.
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
@@ -0,0 +1,285 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// String renders the tree in infix notation; see MinimalInfix.
+func (n *Node) String() string {
+	return n.MinimalInfix()
+}
+
+// precedence ranks operators by how tightly they bind. Unary operators
+// carry their own parentheses, so they bind tightest.
+var precedence = map[string]int{"+": 1, "-": 1, "*": 2, "/": 2, "%": 2, "^": 3, "sqrt": 4, "!": 4, "neg": 4}
+
+// MinimalInfix renders the tree in infix notation with only the
+// parentheses that operator precedence requires, e.g. "1 + 2 + 3 + 4" for
+// ((1 + 2) + 3) + 4 and "8 / (3 - 8 / 3)". Operators of equal precedence
+// group to the left, so a right operand of - or / at the same precedence
+// keeps its parentheses: "8 - (3 - 1)", as does one of %, which * and /
+// do not undo: "2 * (7 % 4)". ^ groups to the right instead, so
+// it is the left operand that keeps them: "(2 ^ 3) ^ 2". Negative numbers
+// and negations are parenthesized when they are operands, as in
+// "8 - (-3)".
+func (n *Node) MinimalInfix() string {
+	return n.minimalInfix(func(leaf *Node) string { return formatNumber(leaf.Value) })
+}
+
+// Template renders the tree as MinimalInfix does but with its numbers
+// replaced by the letters a, b, c, ... in the order they appear, e.g.
+// "a / (b - c / d)" for 8 / (3 - 8 / 3): the shape of the expression, as
+// a template of solver.SolveTemplate. Leaves gives the numbers in the same
+// order.
+func (n *Node) Template() string {
+	letter := 'a'
+	return n.minimalInfix(func(*Node) string {
+		letter++
+		return string(letter - 1)
+	})
+}
+
+// minimalInfix is MinimalInfix with every leaf rendered by leaf.
+func (n *Node) minimalInfix(leaf func(*Node) string) string {
+	if n.IsLeaf() {
+		return leaf(n)
+	}
+	render := func(m *Node) string { return m.minimalInfix(leaf) }
+	if n.IsUnary() {
+		return applyUnary(n.Op, render(n.Left), n.Left.atomic())
+	}
+	left := n.Left.operand(render)
+	if !n.Left.IsLeaf() && (precedence[n.Left.Op] < precedence[n.Op] || n.Op == "^" && n.Left.Op == "^") {
+		left = "(" + left + ")"
+	}
+	right := n.Right.operand(render)
+	if !n.Right.IsLeaf() {
+		p, parent := precedence[n.Right.Op], precedence[n.Op]
+		if p < parent || (p == parent && (n.Op == "-" || n.Op == "/" || n.Op == "%" || n.Right.Op == "%")) {
+			right = "(" + right + ")"
+		}
+	}
+	return left + " " + n.Op + " " + right
+}
+
+// Infix renders the tree in infix notation with every sub-expression
+// parenthesized, e.g. "8 / (3 - (8 / 3))".
+func (n *Node) Infix() string {
+	if n.IsLeaf() {
+		return formatNumber(n.Value)
+	}
+	if n.IsUnary() {
+		return applyUnary(n.Op, n.Left.Infix(), n.Left.atomic())
+	}
+	wrap := func(child *Node) string {
+		if child.IsLeaf() || child.IsUnary() {
+			return child.operand((*Node).Infix)
+		}
+		return "(" + child.Infix() + ")"
+	}
+	return wrap(n.Left) + " " + n.Op + " " + wrap(n.Right)
+}
+
+// latexOps are the LaTeX commands of the binary operators other than /,
+// which becomes a fraction.
+var latexOps = map[string]string{"+": "+", "-": "-", "*": `\times`, "%": `\bmod`}
+
+// LaTeX renders the tree as a LaTeX math expression, without the
+// surrounding $ signs: divisions become fractions, multiplications \times
+// and remainders \bmod, e.g. "\frac{8}{3 - \frac{8}{3}}". The operands of
+// a fraction and an exponent need no parentheses, and a fraction needs
+// none as an operand, so only those that precedence requires otherwise
+// are kept, as in MinimalInfix, written \left( and \right) so they grow
+// with what they enclose: "\left(1 + 2 + 3\right) \times 4".
+func (n *Node) LaTeX() string {
+	if n.IsLeaf() {
+		return formatNumber(n.Value)
+	}
+	if n.IsUnary() {
+		x := n.Left.LaTeX()
+		switch n.Op {
+		case "sqrt":
+			return `\sqrt{` + x + `}`
+		case "neg":
+			if !n.Left.atomic() && n.Left.Op != "/" {
+				x = `\left(` + x + `\right)`
+			}
+			return "-" + x
+		}
+		if !n.Left.atomic() {
+			x = `\left(` + x + `\right)`
+		}
+		return x + "!"
+	}
+	switch n.Op {
+	case "/":
+		return `\frac{` + n.Left.LaTeX() + `}{` + n.Right.LaTeX() + `}`
+	case "^":
+		base := n.Left.LaTeX()
+		if !n.Left.atomic() {
+			base = `\left(` + base + `\right)`
+		}
+		return base + "^{" + n.Right.LaTeX() + "}"
+	}
+	p := precedence[n.Op]
+	left := n.Left.LaTeX()
+	if n.Left.latexLower(p) || n.Left.IsLeaf() && n.Left.Value < 0 || n.Left.IsUnary() && n.Left.Op == "neg" {
+		left = `\left(` + left + `\right)`
+	}
+	right := n.Right.LaTeX()
+	if n.Right.latexLower(p) || n.Right.latexLower(p+1) && (n.Op == "-" || n.Op == "%" || n.Right.Op == "%") ||
+		n.Right.IsLeaf() && n.Right.Value < 0 || n.Right.IsUnary() && n.Right.Op == "neg" {
+		right = `\left(` + right + `\right)`
+	}
+	return left + " " + latexOps[n.Op] + " " + right
+}
+
+// latexLower reports whether n is a binary operation, other than a
+// fraction or power, that binds less tightly than precedence p.
+func (n *Node) latexLower(p int) bool {
+	return !n.IsLeaf() && !n.IsUnary() && n.Op != "/" && n.Op != "^" && precedence[n.Op] < p
+}
+
+// operand renders n with render as the operand of a binary operator, with
+// parentheses around a negative number or a negation.
+func (n *Node) operand(render func(*Node) string) string {
+	if n.IsLeaf() && n.Value < 0 || n.IsUnary() && n.Op == "neg" {
+		return "(" + render(n) + ")"
+	}
+	return render(n)
+}
+
+// applyUnary renders the unary operator op applied to the rendered
+// operand x, e.g. "sqrt(9)", "4!" or "-4". Unless atomic is set, a
+// factorial or negation puts x in parentheses: "(1 + 3)!", "-(1 + 3)".
+func applyUnary(op, x string, atomic bool) string {
+	if op == "sqrt" {
+		return op + "(" + x + ")"
+	}
+	if !atomic {
+		x = "(" + x + ")"
+	}
+	if op == "neg" {
+		return "-" + x
+	}
+	return x + "!"
+}
+
+// atomic reports whether n renders as a single unit that a factorial or
+// negation can apply to without parentheses: a number that is not
+// negative, or a square root. A factorial keeps them, as in (3!)! and
+// -(3!), so it cannot be taken for the double factorial 3!! or the
+// factorial of -3.
+func (n *Node) atomic() bool {
+	if n.IsLeaf() {
+		return n.Value >= 0
+	}
+	return n.IsUnary() && n.Op == "sqrt"
+}
+
+// Prefix renders the tree in prefix (Polish) notation, operators before
+// their operands, e.g. "/ 8 - 3 / 8 3".
+func (n *Node) Prefix() string {
+	var tokens []string
+	n.walk(func(node *Node) { tokens = append(tokens, node.token()) }, nil)
+	return strings.Join(tokens, " ")
+}
+
+// Postfix renders the tree in postfix (reverse Polish) notation, operators
+// after their operands, e.g. "8 3 8 3 / - /".
+func (n *Node) Postfix() string {
+	var tokens []string
+	n.walk(nil, func(node *Node) { tokens = append(tokens, node.token()) })
+	return strings.Join(tokens, " ")
+}
+
+// token is the node's own symbol: its operator, or its number for a leaf.
+func (n *Node) token() string {
+	if n.IsLeaf() {
+		return formatNumber(n.Value)
+	}
+	return n.Op
+}
+
+// walk visits the tree depth first, calling pre before a node's children
+// and post after them. Either may be nil.
+func (n *Node) walk(pre, post func(*Node)) {
+	if pre != nil {
+		pre(n)
+	}
+	if !n.IsLeaf() {
+		n.Left.walk(pre, post)
+		if n.Right != nil {
+			n.Right.walk(pre, post)
+		}
+	}
+	if post != nil {
+		post(n)
+	}
+}
+
+// DOT renders the tree as a Graphviz digraph, e.g. for dot -Tpng: every
+// operation is an ellipse with its operator and the value it makes, and
+// every number a box, with the left operand drawn left of the right one.
+// label, when not empty, is printed under the graph, e.g. the formula.
+func (n *Node) DOT(label string) string {
+	var b strings.Builder
+	b.WriteString("digraph solution {\n\tordering=out;\n")
+	if label != "" {
+		fmt.Fprintf(&b, "\tlabel=%s;\n\tlabelloc=b;\n", strconv.Quote(label))
+	}
+	ids := make(map[*Node]int)
+	n.walk(func(node *Node) {
+		id := len(ids)
+		ids[node] = id
+		if node.IsLeaf() {
+			fmt.Fprintf(&b, "\tn%d [shape=box, label=%s];\n", id, strconv.Quote(formatNumber(node.Value)))
+			return
+		}
+		fmt.Fprintf(&b, "\tn%d [label=%s];\n", id, strconv.Quote(node.Op+"\n= "+roundedNumber(node.Value)))
+	}, nil)
+	n.walk(func(node *Node) {
+		for _, child := range []*Node{node.Left, node.Right} {
+			if child != nil {
+				fmt.Fprintf(&b, "\tn%d -> n%d;\n", ids[node], ids[child])
+			}
+		}
+	}, nil)
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// TextTree renders the tree for a terminal, one node per line under the
+// operation it is an operand of, left operand first, drawn with box
+// characters. Operations show the value they make, so the order of
+// evaluation reads from the bottom up:
+//
+//	/ = 24
+//	├── 8
+//	└── - = 0.333
+//	    ├── 3
+//	    └── / = 2.667
+//	        ├── 8
+//	        └── 3
+func (n *Node) TextTree() string {
+	var b strings.Builder
+	var draw func(node *Node, first, rest string)
+	draw = func(node *Node, first, rest string) {
+		b.WriteString(first)
+		if node.IsLeaf() {
+			b.WriteString(formatNumber(node.Value) + "\n")
+			return
+		}
+		b.WriteString(node.Op + " = " + roundedNumber(node.Value) + "\n")
+		if node.IsUnary() {
+			draw(node.Left, rest+"└── ", rest+"    ")
+			return
+		}
+		draw(node.Left, rest+"├── ", rest+"│   ")
+		draw(node.Right, rest+"└── ", rest+"    ")
+	}
+	draw(n, "", "")
+	return b.String()
+}
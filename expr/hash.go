@@ -0,0 +1,90 @@
+package expr
+
+import (
+	"math"
+	"slices"
+)
+
+// oneHash is the canonical hash of the leaf 1, which rule 2 of
+// CanonicalKey drops from products, quotients and powers.
+var oneHash = leafHash(1)
+
+// CanonicalHash returns a 64-bit hash of the canonical key of node: trees
+// with the same CanonicalKey always get the same hash, and trees with
+// different keys share one only by chance. It follows the same rules as
+// CanonicalKey, sorting child hashes instead of child keys, but builds no
+// strings, so it is the cheap choice for deduplication. Use CanonicalKey
+// when the key has to be shown or stored.
+func CanonicalHash(node *Node) uint64 {
+	return canonicalHash(denegate(node))
+}
+
+func canonicalHash(node *Node) uint64 {
+	if node.IsLeaf() {
+		return leafHash(node.Value)
+	}
+	if node.IsUnary() {
+		return mix(opHash(node.Op), canonicalHash(node.Left))
+	}
+	hashL := canonicalHash(node.Left)
+	hashR := canonicalHash(node.Right)
+
+	if node.Op == "*" {
+		if hashL == oneHash {
+			return hashR
+		}
+		if hashR == oneHash {
+			return hashL
+		}
+	}
+	if (node.Op == "/" || node.Op == "^") && hashR == oneHash {
+		return hashL
+	}
+
+	if node.Op == "+" || node.Op == "*" {
+		var buf [8]uint64
+		operands := collectHashes(node, node.Op, buf[:0])
+		slices.Sort(operands)
+		h := opHash(node.Op)
+		for _, operand := range operands {
+			h = mix(h, operand)
+		}
+		return h
+	}
+	return mix(mix(opHash(node.Op), hashL), hashR)
+}
+
+// collectHashes is the CanonicalHash counterpart of collectOperands.
+func collectHashes(node *Node, op string, operands []uint64) []uint64 {
+	if node.Op == op {
+		operands = collectHashes(node.Left, op, operands)
+		return collectHashes(node.Right, op, operands)
+	}
+	return append(operands, canonicalHash(node))
+}
+
+// leafHash hashes a number. Numbers with the same shortest form, the leaf
+// key of CanonicalKey, have the same bits.
+func leafHash(v float64) uint64 {
+	return mix(0, math.Float64bits(v))
+}
+
+// opHash seeds the hash of an operation with its operator (FNV-1a).
+func opHash(op string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(op); i++ {
+		h ^= uint64(op[i])
+		h *= 1099511628211
+	}
+	return h
+}
+
+// mix folds x into h. It is not symmetric, so the order of operands counts.
+func mix(h, x uint64) uint64 {
+	h ^= x
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 31
+	h *= 0x94d049bb133111eb
+	h ^= h >> 29
+	return h
+}
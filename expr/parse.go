@@ -0,0 +1,265 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// symbols maps the typographic operators people paste into answers to the
+// ASCII operators used in trees.
+var symbols = map[rune]rune{'×': '*', '÷': '/', '−': '-'}
+
+// Normalize replaces the digits of every script, e.g. the full-width ３
+// or the Arabic-Indic ٣, with the ASCII digits they stand for, and the
+// full-width forms of other ASCII characters and the ideographic space,
+// which input methods type, with the characters themselves, so "３，３
+// ８×８" reads as "3,3 8×8".
+func Normalize(input string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r < utf8.RuneSelf:
+			return r
+		case r == '\u3000':
+			return ' '
+		case r >= '！' && r <= '～':
+			return r - '！' + '!'
+		case unicode.IsDigit(r):
+			// Digits are encoded in runs of 0 to 9 in order.
+			start := r
+			for unicode.IsDigit(start - 1) {
+				start--
+			}
+			return '0' + (r-start)%10
+		}
+		return r
+	}, input)
+}
+
+// parser is a small recursive-descent parser for infix expressions such as
+// "8/(3-8/3)". Letters are allowed as placeholders when parsing templates.
+type parser struct {
+	input  []rune
+	pos    int
+	leaves []*Node
+	names  map[rune]bool
+}
+
+func (p *parser) peek() rune {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	if c, ok := symbols[p.input[p.pos]]; ok {
+		return c
+	}
+	return p.input[p.pos]
+}
+
+// parseExpr parses a sum: term (("+" | "-") term)*
+func (p *parser) parseExpr() (*Node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for c := p.peek(); c == '+' || c == '-'; c = p.peek() {
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &Node{Op: string(c), Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parseTerm parses a product: power (("*" | "/" | "%") power)*
+func (p *parser) parseTerm() (*Node, error) {
+	left, err := p.parsePower()
+	if err != nil {
+		return nil, err
+	}
+	for c := p.peek(); c == '*' || c == '/' || c == '%'; c = p.peek() {
+		p.pos++
+		right, err := p.parsePower()
+		if err != nil {
+			return nil, err
+		}
+		left = &Node{Op: string(c), Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parsePower parses a power: postfix ("^" power)?, grouping to the right
+// so 2^3^2 is 2^(3^2).
+func (p *parser) parsePower() (*Node, error) {
+	base, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != '^' {
+		return base, nil
+	}
+	p.pos++
+	exponent, err := p.parsePower()
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Op: "^", Left: base, Right: exponent}, nil
+}
+
+// parsePostfix parses a factor followed by any number of factorial signs:
+// factor "!"*
+func (p *parser) parsePostfix() (*Node, error) {
+	node, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == '!' {
+		p.pos++
+		node = &Node{Op: "!", Left: node}
+	}
+	return node, nil
+}
+
+// parseFactor parses a placeholder, a number, which may be negative as in
+// "-3", a parenthesized expression, a square root written "sqrt(9)" or
+// "√9", or a negation such as "-(1+3)".
+func (p *parser) parseFactor() (*Node, error) {
+	c := p.peek()
+	if c == '√' || c == 's' && strings.HasPrefix(string(p.input[p.pos:]), "sqrt(") {
+		if c == '√' {
+			p.pos++
+		} else {
+			p.pos += len("sqrt")
+		}
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Op: "sqrt", Left: operand}, nil
+	}
+	if c == '-' && p.pos+1 < len(p.input) && p.input[p.pos+1] >= '0' && p.input[p.pos+1] <= '9' {
+		p.pos++
+		leaf, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		leaf.Value = -leaf.Value
+		return leaf, nil
+	}
+	if c == '-' {
+		p.pos++
+		operand, err := p.parsePostfix()
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Op: "neg", Left: operand}, nil
+	}
+	switch {
+	case c == '(':
+		p.pos++
+		node, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ')' at position %d", p.pos+1)
+		}
+		p.pos++
+		return node, nil
+	case c >= 'a' && c <= 'z':
+		p.pos++
+		if p.names[c] {
+			return nil, fmt.Errorf("placeholder '%c' is used more than once", c)
+		}
+		p.names[c] = true
+		leaf := &Node{}
+		p.leaves = append(p.leaves, leaf)
+		return leaf, nil
+	case c >= '0' && c <= '9':
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '.' || p.input[p.pos] >= '0' && p.input[p.pos] <= '9') {
+			p.pos++
+		}
+		value, err := strconv.ParseFloat(string(p.input[start:p.pos]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", string(p.input[start:p.pos]))
+		}
+		return &Node{Value: value}, nil
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected '%c' at position %d", c, p.pos+1)
+}
+
+// parse parses input into a tree, returning the placeholder leaves in the
+// order they appear.
+func parse(input string) (*Node, []*Node, error) {
+	p := &parser{input: []rune(strings.ToLower(Normalize(input))), names: make(map[rune]bool)}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.peek() != 0 {
+		return nil, nil, fmt.Errorf("unexpected '%c' at position %d", p.peek(), p.pos+1)
+	}
+	return root, p.leaves, nil
+}
+
+// Parse reads an infix expression such as "8/(3-8/3)" into a tree, using
+// the usual precedence: the unary sqrt(x) and x! bind tightest, then ^,
+// then * and /, then + and -. Operators of equal precedence group to the
+// left, except ^, which groups to the right. × ÷ − and √ are accepted as
+// * / - and sqrt, and digits and full-width characters as Normalize reads
+// them. The Value of each internal node is computed where it is
+// defined.
+func Parse(input string) (*Node, error) {
+	root, leaves, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(leaves) != 0 {
+		return nil, fmt.Errorf("expression must only contain numbers")
+	}
+	root.fillValues()
+	return root, nil
+}
+
+// ParseTemplate is like Parse but also accepts letters as placeholders,
+// each of which may appear once, e.g. "(a+b)*(c+d)". It returns the tree
+// skeleton and its placeholder leaves in the order they appear, ready to
+// have their Value set. Internal node values are left zero.
+func ParseTemplate(input string) (*Node, []*Node, error) {
+	return parse(input)
+}
+
+// fillValues sets the Value of every internal node from its children,
+// leaving it zero where the sub-expression is undefined.
+func (n *Node) fillValues() {
+	if n.IsLeaf() {
+		return
+	}
+	n.Left.fillValues()
+	if n.Right != nil {
+		n.Right.fillValues()
+	}
+	if v, err := n.Eval(); err == nil {
+		n.Value = v
+	}
+}
+
+// Leaves returns the leaf values of the tree from left to right.
+func (n *Node) Leaves() []float64 {
+	var values []float64
+	n.walk(func(node *Node) {
+		if node.IsLeaf() {
+			values = append(values, node.Value)
+		}
+	}, nil)
+	return values
+}
@@ -0,0 +1,195 @@
+package expr
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Step is one operation performed while evaluating a tree. A unary
+// operation such as sqrt has its operand in Left and leaves Right zero.
+type Step struct {
+	Left   float64
+	Op     string
+	Right  float64
+	Result float64
+	Unary  bool
+}
+
+// String renders the step with values rounded to three decimals, e.g.
+// "3 - 2.667 = 0.333", "sqrt(9) = 3" or "4! = 24".
+func (s Step) String() string {
+	if s.Unary {
+		// A negated number is written -(3), not to be read as -3.
+		atomic := s.Left >= 0 && s.Op != "neg"
+		return fmt.Sprintf("%s = %s", applyUnary(s.Op, roundedNumber(s.Left), atomic), roundedNumber(s.Result))
+	}
+	return fmt.Sprintf("%s %s %s = %s", roundedNumber(s.Left), s.Op, roundedNumber(s.Right), roundedNumber(s.Result))
+}
+
+// roundedNumber formats n with at most three decimals and no trailing zeros.
+func roundedNumber(n float64) string {
+	s := strconv.FormatFloat(n, 'f', 3, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// Steps returns the operations of the tree in the order they are
+// evaluated: every operation comes after the operations producing its
+// operands, left operand first. The values are the ones stored on the
+// nodes, so the tree must be evaluated, as solver trees and Parse results
+// are.
+func (n *Node) Steps() []Step {
+	var steps []Step
+	n.walk(nil, func(node *Node) {
+		switch {
+		case node.IsUnary():
+			steps = append(steps, Step{Left: node.Left.Value, Op: node.Op, Result: node.Value, Unary: true})
+		case !node.IsLeaf():
+			steps = append(steps, Step{Left: node.Left.Value, Op: node.Op, Right: node.Right.Value, Result: node.Value})
+		}
+	})
+	return steps
+}
+
+// Derivation renders the steps of the tree, in the order of Steps, with
+// exact values: whole numbers, fractions and mixed numbers as they are
+// written by hand, e.g. "8 / 3 = 2 2/3", "3 - (2 2/3) = 1/3" and
+// "8 / (1/3) = 24". Operands that are not whole are bracketed, so
+// 8 / (1/3) cannot be read as 8 / 1 / 3. A value that is not a fraction,
+// such as sqrt(2), is rounded to three decimals as Step.String does.
+func (n *Node) Derivation() []string {
+	var lines []string
+	exact := make(map[*Node]*big.Rat)
+	n.walk(nil, func(node *Node) {
+		exact[node] = node.exactValue(exact)
+		if node.IsLeaf() {
+			return
+		}
+		left := fractionString(node.Left, exact)
+		result := fractionString(node, exact)
+		if node.IsUnary() {
+			// As in Step.String, and a fraction is bracketed: (1/2)!.
+			atomic := node.Left.Value >= 0 && node.Op != "neg" && !strings.ContainsAny(left, " /")
+			lines = append(lines, fmt.Sprintf("%s = %s", applyUnary(node.Op, left, atomic), result))
+			return
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s = %s", bracketFraction(left), node.Op, bracketFraction(fractionString(node.Right, exact)), result))
+	})
+	return lines
+}
+
+// exactValue computes the value of node as a fraction from the values of
+// its operands in exact, or returns nil when it is not one: an operand is
+// not, the value is irrational, or the operation is undefined.
+func (n *Node) exactValue(exact map[*Node]*big.Rat) *big.Rat {
+	if n.IsLeaf() {
+		r, _ := new(big.Rat).SetString(formatNumber(n.Value))
+		return r
+	}
+	l := exact[n.Left]
+	if l == nil {
+		return nil
+	}
+	if n.IsUnary() {
+		return exactUnary(n.Op, l)
+	}
+	r := exact[n.Right]
+	if r == nil {
+		return nil
+	}
+	switch n.Op {
+	case "+":
+		return new(big.Rat).Add(l, r)
+	case "-":
+		return new(big.Rat).Sub(l, r)
+	case "*":
+		return new(big.Rat).Mul(l, r)
+	case "/":
+		if r.Sign() == 0 {
+			return nil
+		}
+		return new(big.Rat).Quo(l, r)
+	case "^":
+		// Exponents beyond 64 are not worth the size of the fraction.
+		if !r.IsInt() || !r.Num().IsInt64() || r.Num().Int64() > 64 || r.Num().Int64() < -64 || l.Sign() == 0 && r.Sign() <= 0 {
+			return nil
+		}
+		k := r.Num().Int64()
+		base := new(big.Rat).Set(l)
+		if k < 0 {
+			base.Inv(base)
+			k = -k
+		}
+		result := big.NewRat(1, 1)
+		for range k {
+			result.Mul(result, base)
+		}
+		return result
+	case "%":
+		if !l.IsInt() || !r.IsInt() || r.Sign() == 0 {
+			return nil
+		}
+		return new(big.Rat).SetInt(new(big.Int).Rem(l.Num(), r.Num()))
+	}
+	return nil
+}
+
+// exactUnary applies the unary operator op to the fraction x, as
+// exactValue does.
+func exactUnary(op string, x *big.Rat) *big.Rat {
+	switch op {
+	case "neg":
+		return new(big.Rat).Neg(x)
+	case "!":
+		if !x.IsInt() || x.Sign() < 0 || x.Num().Cmp(big.NewInt(170)) > 0 {
+			return nil
+		}
+		return new(big.Rat).SetInt(new(big.Int).MulRange(1, max(x.Num().Int64(), 1)))
+	case "sqrt":
+		if x.Sign() < 0 {
+			return nil
+		}
+		num, denom := new(big.Int).Sqrt(x.Num()), new(big.Int).Sqrt(x.Denom())
+		if new(big.Int).Mul(num, num).Cmp(x.Num()) != 0 || new(big.Int).Mul(denom, denom).Cmp(x.Denom()) != 0 {
+			return nil
+		}
+		return new(big.Rat).SetFrac(num, denom)
+	}
+	return nil
+}
+
+// bracketFraction puts a fraction or mixed number rendered by
+// fractionString in parentheses, to be the operand of a binary operator.
+func bracketFraction(s string) string {
+	if strings.ContainsAny(s, " /") {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+// fractionString renders the value of node: its exact value as a whole,
+// proper or mixed number, e.g. "24", "1/3" or "-2 2/3", or else its value
+// rounded.
+func fractionString(node *Node, exact map[*Node]*big.Rat) string {
+	r := exact[node]
+	if r == nil {
+		return roundedNumber(node.Value)
+	}
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	sign := ""
+	if r.Sign() < 0 {
+		sign = "-"
+	}
+	whole, rest := new(big.Int).QuoRem(new(big.Int).Abs(r.Num()), r.Denom(), new(big.Int))
+	if whole.Sign() == 0 {
+		return fmt.Sprintf("%s%s/%s", sign, rest, r.Denom())
+	}
+	return fmt.Sprintf("%s%s %s/%s", sign, whole, rest, r.Denom())
+}
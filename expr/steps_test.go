@@ -0,0 +1,32 @@
+package expr_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/x0root/24Solver/expr"
+)
+
+func TestDerivation(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"8/(3-8/3)", []string{"8 / 3 = 2 2/3", "3 - (2 2/3) = 1/3", "8 / (1/3) = 24"}},
+		{"(5-1/5)*5", []string{"1 / 5 = 1/5", "5 - (1/5) = 4 4/5", "(4 4/5) * 5 = 24"}},
+		{"(1-4/3)*(0-72)", []string{"4 / 3 = 1 1/3", "1 - (1 1/3) = -1/3", "0 - 72 = -72", "(-1/3) * -72 = 24"}},
+		{"(10*10-4)/4", []string{"10 * 10 = 100", "100 - 4 = 96", "96 / 4 = 24"}},
+		{"(1+1+1+1)!", []string{"1 + 1 = 2", "2 + 1 = 3", "3 + 1 = 4", "4! = 24"}},
+		{"sqrt(1/4)*48", []string{"1 / 4 = 1/4", "sqrt(1/4) = 1/2", "(1/2) * 48 = 24"}},
+		{"sqrt(2)*2", []string{"sqrt(2) = 1.414", "1.414 * 2 = 2.828"}},
+	}
+	for _, tt := range tests {
+		tree, err := expr.Parse(tt.input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.input, err)
+		}
+		if got := tree.Derivation(); !slices.Equal(got, tt.want) {
+			t.Errorf("Derivation(%s) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package expr
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// The layout of SVG, in pixels.
+const (
+	svgColumn = 64 // between the centers of neighbouring numbers
+	svgRow    = 80 // between the levels of the tree
+	svgRadius = 24 // of the circle of an operation
+	svgMargin = 40 // around the tree
+	svgLabel  = 36 // for the label under the tree
+	// svgCharWidth is about the width of a character of the label.
+	svgCharWidth = 9
+)
+
+// SVG renders the tree as a self-contained SVG image, e.g. to put on a
+// slide: numbers are boxes, spread out from left to right in the order they
+// appear, and every operation is a circle centered above its operands, with
+// its operator and, under it, the value it makes. label, when not empty, is
+// printed under the tree, e.g. the formula.
+func (n *Node) SVG(label string) string {
+	type point struct{ x, y float64 }
+	at := make(map[*Node]point)
+	leaves, depth := 0, 0
+	var place func(node *Node, level int) float64
+	place = func(node *Node, level int) float64 {
+		depth = max(depth, level)
+		var x float64
+		switch {
+		case node.IsLeaf():
+			x = float64(leaves) * svgColumn
+			leaves++
+		case node.IsUnary():
+			x = place(node.Left, level+1)
+		default:
+			x = (place(node.Left, level+1) + place(node.Right, level+1)) / 2
+		}
+		at[node] = point{x + svgMargin, float64(level)*svgRow + svgMargin}
+		return x
+	}
+	place(n, 0)
+
+	width := float64(leaves-1)*svgColumn + 2*svgMargin
+	height := float64(depth)*svgRow + 2*svgMargin
+	if label != "" {
+		height += svgLabel
+		// Widen the image for a long label, keeping the tree centered.
+		if wide := float64(utf8.RuneCountInString(label))*svgCharWidth + 2*svgMargin; wide > width {
+			for node, p := range at {
+				at[node] = point{p.x + (wide-width)/2, p.y}
+			}
+			width = wide
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g" font-family="sans-serif" text-anchor="middle">`+"\n", width, height, width, height)
+	b.WriteString(`<rect width="100%" height="100%" fill="white"/>` + "\n")
+	// Edges first, so the nodes are drawn over their ends.
+	n.walk(func(node *Node) {
+		for _, child := range []*Node{node.Left, node.Right} {
+			if child != nil {
+				fmt.Fprintf(&b, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="black"/>`+"\n", at[node].x, at[node].y, at[child].x, at[child].y)
+			}
+		}
+	}, nil)
+	n.walk(func(node *Node) {
+		p := at[node]
+		if node.IsLeaf() {
+			fmt.Fprintf(&b, `<rect x="%g" y="%g" width="%d" height="%d" rx="4" fill="#eef" stroke="black"/>`+"\n", p.x-svgRadius, p.y-svgRadius*3/4, 2*svgRadius, svgRadius*3/2)
+			fmt.Fprintf(&b, `<text x="%g" y="%g" font-size="16">%s</text>`+"\n", p.x, p.y+6, html.EscapeString(formatNumber(node.Value)))
+			return
+		}
+		fmt.Fprintf(&b, `<circle cx="%g" cy="%g" r="%d" fill="#ffe" stroke="black"/>`+"\n", p.x, p.y, svgRadius)
+		fmt.Fprintf(&b, `<text x="%g" y="%g" font-size="16">%s</text>`+"\n", p.x, p.y+2, html.EscapeString(node.Op))
+		fmt.Fprintf(&b, `<text x="%g" y="%g" font-size="10">%s</text>`+"\n", p.x, p.y+15, html.EscapeString(roundedNumber(node.Value)))
+	}, nil)
+	if label != "" {
+		fmt.Fprintf(&b, `<text x="%g" y="%g" font-size="16">%s</text>`+"\n", width/2, height-svgMargin/2, html.EscapeString(label))
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
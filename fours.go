@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// runFours handles the fours subcommand, the four fours puzzle: it lists
+// every whole number in a range that copies of one number can make, with
+// an expression for each, and the numbers it cannot. The operator flags
+// of the main flags apply, e.g. -sqrt 1 -factorial 4 -concat for the
+// usual four fours rules.
+func runFours(opts []solver.Option, args []string) int {
+	fs := flag.NewFlagSet("fours", flag.ContinueOnError)
+	number := fs.Float64("number", 4, "the number each copy is")
+	copies := fs.Int("copies", 4, "how many copies of the number to use, from 1 to 6")
+	from := fs.Int("from", 1, "the first target")
+	to := fs.Int("to", 100, "the last target")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *copies < 1 || *copies > 6 {
+		errorf("-copies must be from 1 to 6, not %d", *copies)
+		return 2
+	}
+	if *from > *to {
+		errorf("-from %d is above -to %d", *from, *to)
+		return 2
+	}
+
+	nums := make([]float64, *copies)
+	for i := range nums {
+		nums[i] = *number
+	}
+	slv := solver.New(append(opts, solver.WithLargeNumbers(true), solver.WithZeroAndNegatives(true), solver.WithFractions(true))...)
+	printf("Targets %d to %d with %s:\n\n", *from, *to, formatHand(nums))
+	if _, err := printReachable(slv, nums, *from, *to); err != nil {
+		errorf("%s", err)
+		return 1
+	}
+	return 0
+}
+
+// printReachable prints an expression for every whole number from low to
+// high that nums can make, then the numbers they cannot, and returns the
+// expressions.
+func printReachable(slv *solver.Solver, nums []float64, low, high int) ([]solver.Solution, error) {
+	solutions, err := slv.Reachable(nums, low, high)
+	if err != nil {
+		return nil, err
+	}
+	reached := make(map[int]bool)
+	for _, solution := range solutions {
+		fmt.Printf("%s = %s\n", formatNumber(solution.Value), displayFormula(solution.Formula))
+		reached[int(math.Round(solution.Value))] = true
+	}
+	var missing []string
+	for target := low; target <= high; target++ {
+		if !reached[target] {
+			missing = append(missing, strconv.Itoa(target))
+		}
+	}
+	printf("\nReached %d of %d target(s).\n", len(solutions), high-low+1)
+	if len(missing) > 0 {
+		printf("Not reached: %s\n", strings.Join(missing, ", "))
+	}
+	return solutions, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// maxDeals is how many hands deal tries before giving up on finding one
+// of the wanted difficulty.
+const maxDeals = 10000
+
+// runGen handles the gen command: it deals random solvable hands from a
+// deck of cards, e.g. "gen -difficulty hard -n 5", one per line with its
+// difficulty, see solver.Rate. The hand size follows -count and the rules
+// the other main flags.
+func runGen(opts []solver.Option, args []string) int {
+	fs := flag.NewFlagSet("gen", flag.ContinueOnError)
+	difficulty := fs.String("difficulty", "", "only deal hands that are `easy`, medium or hard (default any solvable hand)")
+	n := fs.Int("n", 1, "how many hands to deal")
+	seed := fs.Uint64("seed", 0, "seed for the deals, so they can be replayed (0 picks one)")
+	answers := fs.Bool("answers", false, "print the most elegant solution after each hand")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	want, err := parseWantedDifficulty(*difficulty)
+	if err != nil {
+		errorf("%s", err)
+		return 2
+	}
+
+	slv := solver.New(opts...)
+	rng := newRand(*seed)
+	for range *n {
+		nums, solutions, rating, err := deal(slv, rng, want)
+		if err != nil {
+			errorf("%s", err)
+			return 1
+		}
+		if *answers {
+			solver.Sort(solutions, solver.SortElegance)
+			fmt.Printf("%s (%s): %s\n", formatHand(nums), rating, displayFormula(solutions[0].Formula))
+		} else {
+			fmt.Printf("%s (%s)\n", formatHand(nums), rating)
+		}
+	}
+	return 0
+}
+
+// parseWantedDifficulty parses a -difficulty flag, where the empty string
+// stands for any difficulty.
+func parseWantedDifficulty(name string) (solver.Difficulty, error) {
+	if name == "" {
+		return "", nil
+	}
+	return solver.ParseDifficulty(name)
+}
+
+// newRand returns a random source seeded with seed, or with a random seed
+// when it is 0.
+func newRand(seed uint64) *rand.Rand {
+	if seed == 0 {
+		return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return rand.New(rand.NewPCG(seed, 0))
+}
+
+// deal deals hands of -count cards, 4 by default, from a shuffled deck of
+// 52 until one is solvable by slv and, unless want is empty, of difficulty
+// want. It returns the hand with its solutions and difficulty. Standard
+// hands that slv.Lookup shows to be unsolvable are dealt again without a
+// search.
+func deal(slv *solver.Solver, rng *rand.Rand, want solver.Difficulty) ([]float64, []solver.Solution, solver.Difficulty, error) {
+	size := *count
+	if size == 0 {
+		size = 4
+	}
+	var deck []float64
+	for card := 1; card <= 13; card++ {
+		for range 4 {
+			deck = append(deck, float64(card))
+		}
+	}
+	for range maxDeals {
+		rng.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
+		nums := slices.Clone(deck[:size])
+		if knownUnsolvable(slv, nums) {
+			continue
+		}
+		solutions, err := slv.Solve(nums)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		rating, ok := solver.Rate(solutions)
+		if ok && (want == "" || rating == want) {
+			return nums, solutions, rating, nil
+		}
+	}
+	if want == "" {
+		return nil, nil, "", newError("no solvable hand found in %d deals", maxDeals)
+	}
+	return nil, nil, "", newError("no %s hand found in %d deals", want, maxDeals)
+}
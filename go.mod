@@ -0,0 +1,11 @@
+module github.com/x0root/24Solver
+
+go 1.23
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/chzyer/readline v1.5.1
+	golang.org/x/text v0.22.0
+)
+
+require golang.org/x/sys v0.0.0-20220310020820-b874c991c1a5 // indirect
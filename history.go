@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// historyLimit is how many hands -keep-history keeps.
+const historyLimit = 1000
+
+// defaultHistoryFile is where -keep-history keeps the hands solved:
+// 24solver/history in the user's config directory, next to the config
+// file. It is empty when there is no such directory.
+func defaultHistoryFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "24solver", "history")
+}
+
+// loadHistory reads the hands of the history file at path, one per line
+// with the numbers separated by spaces, skipping lines that are not. A
+// missing file is an empty history. When the file holds more than
+// historyLimit hands, only the last are kept, and the file is rewritten
+// with them.
+func loadHistory(path string) ([][]float64, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var hands [][]float64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if nums, ok := parseHistoryLine(scanner.Text()); ok {
+			hands = append(hands, nums)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(hands) <= historyLimit {
+		return hands, nil
+	}
+	hands = hands[len(hands)-historyLimit:]
+	var b strings.Builder
+	for _, nums := range hands {
+		b.WriteString(formatNumbers(nums) + "\n")
+	}
+	return hands, os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// parseHistoryLine parses a line of the history file.
+func parseHistoryLine(line string) ([]float64, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, false
+	}
+	nums := make([]float64, len(fields))
+	for i, field := range fields {
+		num, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, false
+		}
+		nums[i] = num
+	}
+	return nums, true
+}
+
+// appendHistory adds nums to the history file at path, creating it and
+// its directory if need be.
+func appendHistory(path string, nums []float64) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(formatNumbers(nums) + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
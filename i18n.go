@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// languages are the languages messages can be printed in, English first,
+// since it is the one every message is written in and the fallback.
+var languages = []language.Tag{language.English, language.Spanish}
+
+// printer prints messages in the language of -lang, or is nil for English,
+// which fmt prints unchanged.
+var printer *message.Printer
+
+// printf is fmt.Printf with format translated to the language of -lang,
+// printed as -plain asks, see plainText.
+func printf(format string, args ...any) {
+	fmt.Print(plainText(sprintf(format, args...)))
+}
+
+// sprintf is fmt.Sprintf with format, and the errors among args, translated
+// to the language of -lang, see errorMessage.
+func sprintf(format string, args ...any) string {
+	if printer == nil {
+		return fmt.Sprintf(format, args...)
+	}
+	args = slices.Clone(args)
+	for i, arg := range args {
+		if err, ok := arg.(error); ok {
+			args[i] = errorMessage(err)
+		}
+	}
+	return printer.Sprintf(format, args...)
+}
+
+// newError is fmt.Errorf with format translated to the language of -lang,
+// for errors the user reads. It wraps no error.
+func newError(format string, args ...any) error {
+	return errors.New(sprintf(format, args...))
+}
+
+// translate returns the message s, which has no verbs, in the language of
+// -lang.
+func translate(s string) string {
+	if printer == nil {
+		return s
+	}
+	return printer.Sprintf(s)
+}
+
+// localizer is an error whose message can be formatted in another
+// language, see solver.ErrHandSize.Localize.
+type localizer interface {
+	Localize(sprintf func(format string, args ...any) string) string
+}
+
+// errorMessage returns the message of err in the language of -lang. An
+// error with values in its message is formatted again by sprintf when it
+// can be, and one that wraps another, e.g. "invalid answer: ...", has
+// its prefix and the wrapped error translated apart. Any other error is
+// looked up by its whole text, so only messages without values are
+// translated.
+func errorMessage(err error) string {
+	if printer == nil {
+		return err.Error()
+	}
+	if l, ok := err.(localizer); ok {
+		return l.Localize(sprintf)
+	}
+	msg := err.Error()
+	if inner := errors.Unwrap(err); inner != nil {
+		if prefix, ok := strings.CutSuffix(msg, ": "+inner.Error()); ok {
+			return translate(prefix) + ": " + errorMessage(inner)
+		}
+	}
+	if strings.Contains(msg, "%") {
+		// Not a format, and so not a key of the catalog.
+		return msg
+	}
+	return translate(msg)
+}
+
+// setLanguage selects the language messages are printed in: lang, e.g. es,
+// or when it is empty the one of the locale in LC_ALL, LC_MESSAGES or LANG,
+// e.g. es_ES.UTF-8. A lang that is not one of languages is an error; a
+// locale that is not falls back to English.
+func setLanguage(lang string) error {
+	explicit := lang != ""
+	if !explicit {
+		lang = localeLanguage()
+	}
+	if lang == "" {
+		return nil
+	}
+	tag, err := language.Parse(lang)
+	if err != nil {
+		if explicit {
+			return fmt.Errorf("-lang: unknown language '%s'", lang)
+		}
+		return nil
+	}
+	_, i, confidence := language.NewMatcher(languages).Match(tag)
+	if confidence == language.No {
+		if explicit {
+			return fmt.Errorf("-lang: messages are not available in '%s'; use en or es", lang)
+		}
+		return nil
+	}
+	if i > 0 {
+		printer = message.NewPrinter(languages[i])
+	}
+	return nil
+}
+
+// localeLanguage returns the language of the locale the environment sets,
+// e.g. es-ES for es_ES.UTF-8, or "" for none or the C locale.
+func localeLanguage() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(env)
+		if locale == "" {
+			continue
+		}
+		locale, _, _ = strings.Cut(locale, ".")
+		locale, _, _ = strings.Cut(locale, "@")
+		if locale == "C" || locale == "POSIX" {
+			return ""
+		}
+		return strings.ReplaceAll(locale, "_", "-")
+	}
+	return ""
+}
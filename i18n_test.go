@@ -0,0 +1,95 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// verbs matches the verbs of a format, which carry no text to translate.
+var verbs = regexp.MustCompile(`%[-+# 0-9.*]*[a-zA-Z%]`)
+
+// TestCatalog checks that every message printed through the catalog has a
+// Spanish translation: the literal formats passed here to sprintf,
+// printf, errorf, newError and translate, and in the solver package to the
+// sprintf of the Localize methods, and the messages of errors.New, which
+// errorMessage looks up, here and in the errors the solver and expr
+// packages export.
+func TestCatalog(t *testing.T) {
+	fset := token.NewFileSet()
+	for _, dir := range []string{".", "solver", "expr"} {
+		pkgs, err := parser.ParseDir(fset, dir, func(info fs.FileInfo) bool {
+			return !strings.HasSuffix(info.Name(), "_test.go")
+		}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pkg := range pkgs {
+			for _, file := range pkg.Files {
+				ast.Inspect(file, func(node ast.Node) bool {
+					switch node := node.(type) {
+					case *ast.CallExpr:
+						if fn, ok := node.Fun.(*ast.Ident); ok && len(node.Args) > 0 {
+							switch fn.Name {
+							case "sprintf", "printf", "errorf", "newError", "translate":
+								checkMessage(t, fset, node.Args[0])
+							}
+						}
+						if dir == "." && isErrorsNew(node) {
+							checkMessage(t, fset, node.Args[0])
+						}
+					case *ast.ValueSpec:
+						for i, name := range node.Names {
+							if !name.IsExported() || i >= len(node.Values) {
+								continue
+							}
+							if call, ok := node.Values[i].(*ast.CallExpr); ok && isErrorsNew(call) {
+								checkMessage(t, fset, call.Args[0])
+							}
+						}
+					}
+					return true
+				})
+			}
+		}
+	}
+}
+
+// checkMessage reports a literal message with text to translate that the
+// catalog does not have.
+func checkMessage(t *testing.T, fset *token.FileSet, arg ast.Expr) {
+	t.Helper()
+	lit, ok := arg.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return
+	}
+	msg, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.ContainsFunc(verbs.ReplaceAllString(msg, ""), isLetter) {
+		return
+	}
+	if _, ok := messagesES[msg]; !ok {
+		t.Errorf("%s: %q has no Spanish translation", fset.Position(lit.Pos()), msg)
+	}
+}
+
+// isErrorsNew reports whether call is errors.New with one argument.
+func isErrorsNew(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || len(call.Args) != 1 {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "errors" && sel.Sel.Name == "New"
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
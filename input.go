@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+// lineReader reads the lines of the interactive loop.
+type lineReader interface {
+	// readLine prints prompt and returns the next line, or false at the
+	// end of the input.
+	readLine(prompt string) (string, bool)
+	// remember adds a hand that was typed to the history, when there is
+	// one.
+	remember(line string)
+	close()
+}
+
+// newLineReader returns the reader of the interactive loop: on a terminal
+// one with line editing and a history of the hands solved, recalled with
+// the up arrow, and otherwise one that reads stdin line by line.
+func newLineReader() lineReader {
+	if isTerminal(os.Stdin) {
+		rl, err := readline.NewEx(&readline.Config{DisableAutoSaveHistory: true})
+		if err == nil {
+			return lineEditor{rl}
+		}
+	}
+	return lineScanner{scanLines(os.Stdin)}
+}
+
+// lineScanner is the lineReader of input that is not a terminal.
+type lineScanner struct {
+	lines <-chan string
+}
+
+func (s lineScanner) readLine(prompt string) (string, bool) {
+	fmt.Print(prompt)
+	return nextLine(s.lines)
+}
+
+func (lineScanner) remember(string) {}
+
+func (lineScanner) close() {}
+
+// lineEditor is the lineReader of a terminal, with line editing: the arrow
+// keys, Ctrl-A and Ctrl-E to go to the start and end of the line, and
+// the history.
+type lineEditor struct {
+	rl *readline.Instance
+}
+
+func (e lineEditor) readLine(prompt string) (string, bool) {
+	// The prompt is redrawn as the line is edited, so it must be a single
+	// line.
+	if rest, ok := strings.CutPrefix(prompt, "\n"); ok {
+		fmt.Println()
+		prompt = rest
+	}
+	e.rl.SetPrompt(prompt)
+	for {
+		line, err := e.rl.Readline()
+		switch {
+		case errors.Is(err, readline.ErrInterrupt) && line != "":
+			// Ctrl-C drops the line being typed, Ctrl-C on an empty
+			// line quits as the signal would.
+			continue
+		case errors.Is(err, readline.ErrInterrupt):
+			interrupt()
+			return "", false
+		case err != nil:
+			return "", false
+		}
+		return line, true
+	}
+}
+
+func (e lineEditor) remember(line string) {
+	e.rl.SaveHistory(line)
+}
+
+func (e lineEditor) close() {
+	e.rl.Close()
+}
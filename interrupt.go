@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/signal"
+)
+
+// interrupted is done once Ctrl-C has been pressed in a mode that handles
+// it, see handleInterrupt, and never otherwise. Searches stop when it is
+// done, returning what they have found so far.
+var interrupted = context.Background()
+
+// interrupt ends interrupted as Ctrl-C does, for the line editor, which
+// reads Ctrl-C as a key rather than a signal.
+var interrupt = func() {}
+
+// handleInterrupt makes the first Ctrl-C end interrupted instead of the
+// program, so the interactive solver, the quiz and batch mode can stop
+// cleanly and print a summary. A second Ctrl-C ends the program as usual.
+func handleInterrupt() {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		select {
+		case <-signals:
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+		cancel()
+	}()
+	interrupted, interrupt = ctx, cancel
+}
+
+// isInterrupted reports whether Ctrl-C has been pressed.
+func isInterrupted() bool {
+	return interrupted.Err() != nil
+}
+
+// scanLines sends the lines of r to the channel it returns, which is
+// closed at the end of r, so they can be waited for together with
+// interrupted.
+func scanLines(r io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
+
+// nextLine returns the next line of lines, or false at their end or once
+// Ctrl-C has been pressed.
+func nextLine(lines <-chan string) (string, bool) {
+	select {
+	case line, ok := <-lines:
+		return line, ok
+	case <-interrupted.Done():
+		return "", false
+	}
+}
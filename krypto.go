@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// kryptoDeck is the 56-card Krypto deck: three of each card from 1 to 6,
+// four from 7 to 10, two from 11 to 17 and one from 18 to 25.
+var kryptoDeck = func() []float64 {
+	var deck []float64
+	for card := 1; card <= 25; card++ {
+		copies := 1
+		switch {
+		case card <= 6:
+			copies = 3
+		case card <= 10:
+			copies = 4
+		case card <= 17:
+			copies = 2
+		}
+		for range copies {
+			deck = append(deck, float64(card))
+		}
+	}
+	return deck
+}()
+
+// kryptoCards is how many cards a Krypto hand has, besides the target.
+const kryptoCards = 5
+
+// runKrypto handles the krypto subcommand: a hand of Krypto, five cards
+// from 1 to 25 that must all be used once to make a sixth, the target
+// card, e.g. "krypto 2 4 6 14 20 -> 17". Without cards, a hand is dealt
+// from the Krypto deck. The operators and output flags of the main flags
+// apply.
+func runKrypto(opts []solver.Option, args []string) int {
+	fs := flag.NewFlagSet("krypto", flag.ContinueOnError)
+	seed := fs.Uint64("seed", 0, "seed for the deal, so a hand can be replayed (0 picks one)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var nums []float64
+	var target float64
+	if fs.NArg() > 0 {
+		var err error
+		if nums, target, err = parseKrypto(strings.Join(fs.Args(), " ")); err != nil {
+			errorf("%s", err)
+			return 2
+		}
+	} else {
+		rng := newRand(*seed)
+		deal := slices.Clone(kryptoDeck)
+		rng.Shuffle(len(deal), func(i, j int) { deal[i], deal[j] = deal[j], deal[i] })
+		nums, target = deal[:kryptoCards], deal[kryptoCards]
+	}
+
+	slv := solver.New(append(opts, solver.WithTarget(target), solver.WithLargeNumbers(true))...)
+	printf("Cards: %s -> %s\n", formatHand(nums), formatNumber(target))
+	fmt.Println("===============================")
+	solutions, err := slv.Solve(nums)
+	if err != nil {
+		errorf("%s", err)
+		return 1
+	}
+	if len(solutions) == 0 {
+		printf("No solutions found for these cards.\n")
+		printClosest(slv, nums)
+		return 0
+	}
+	printf("Found %d unique solution(s):\n\n", len(solutions))
+	for i, solution := range solutions {
+		fmt.Printf("%d. %s = %s\n", i+1, displayFormula(solution.Formula), formatNumber(solution.Value))
+	}
+	return 0
+}
+
+// parseKrypto parses a Krypto hand written as five cards, "->" and the
+// target card, e.g. "2 4 6 14 20 -> 17". Every card is from 1 to 25.
+func parseKrypto(input string) ([]float64, float64, error) {
+	cards, targetCard, ok := strings.Cut(input, "->")
+	if !ok {
+		return nil, 0, newError("write the hand as five cards, -> and the target card, e.g. 2 4 6 14 20 -> 17")
+	}
+	nums, err := solver.New(solver.WithLargeNumbers(true)).ParseHandN(cards, kryptoCards)
+	if err != nil {
+		return nil, 0, err
+	}
+	target, err := strconv.ParseFloat(strings.TrimSpace(targetCard), 64)
+	if err != nil {
+		return nil, 0, newError("invalid target card '%s'", strings.TrimSpace(targetCard))
+	}
+	for _, card := range append(slices.Clone(nums), target) {
+		if card < 1 || card > 25 || card != float64(int(card)) {
+			return nil, 0, newError("Krypto cards go from 1 to 25, not %s", formatNumber(card))
+		}
+	}
+	return nums, target, nil
+}
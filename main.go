@@ -1,307 +1,1187 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"math"
+	"math/big"
+	"math/rand/v2"
 	"os"
-	"sort"
+	"slices"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	texttemplate "text/template"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+var (
+	target       = flag.String("target", "24", "the value solutions must make, or several separated by commas, e.g. 24,36,100, to solve each hand for all of them in one search")
+	tolerance    = flag.Float64("tolerance", 0, "also accept solutions within `T` of the target, e.g. 0.5")
+	targetRange  = flag.String("target-range", "", "accept any solution from `LOW..HIGH`, e.g. 20..30, instead of -target")
+	reachable    = flag.String("reachable", "", "instead of solving, list every whole number from `LOW..HIGH`, e.g. 1..100, that a hand can make")
+	ops          = flag.String("ops", "", "the operators solutions may use, e.g. \"+-*/^%\" to allow whole powers and remainders (default \"+-*/\")")
+	sqrtDepth    = flag.Int("sqrt", 0, "allow square roots, nested up to `N` deep, e.g. sqrt(9) with 1 or sqrt(sqrt(16)) with 2")
+	factorials   = flag.Int("factorial", 0, "allow factorials of whole numbers up to `N`, e.g. 4! with 4 or more (at most 20)")
+	negation     = flag.Bool("negation", false, "allow negating numbers and sub-expressions, e.g. -(1 - 2)")
+	concat       = flag.Bool("concat", false, "allow joining adjacent digits of the hand as dealt, e.g. 12 + 3 * 4 for 1 2 3 4")
+	subsets      = flag.Bool("subsets", false, "let solutions use any of the numbers instead of all of them, listing those that use more first")
+	reuse        = flag.Int("reuse", 1, "let each number be used up to `N` times instead of once, in solutions of at most 8 numbers")
+	dedup        = flag.String("dedup", "canonical", "merge solutions as the same one by `LEVEL`: none, syntactic (they print the same), canonical (they differ only in the order and grouping of + and *) or aggressive (also a-b+c and a+c-b, a/(b/c) and a*c/b)")
+	mergeMirrors = flag.Bool("merge-mirrors", false, "also merge solutions that are sign-mirrors of each other, e.g. (a-b)*(c-d) and (b-a)*(d-c)")
+	noColor      = flag.Bool("no-color", false, "do not color the output, which is only colored on a terminal and when NO_COLOR is not set")
+	lang         = flag.String("lang", "", "print messages in `LANG`, en or es (default from the locale in $LANG)")
+	noBanner     = flag.Bool("no-banner", false, "start the interactive solver without the banner and rules")
+	plain        = flag.Bool("plain", false, "print for screen readers and pipes: one line per message and per solution, prefixed by its index, with no banner, separators, blank lines, colors, tables or alignment")
+	quiet        = flag.Bool("quiet", false, "print only results: no banner, rules, prompts or separators, and for a hand only its solutions, one per line, so the exit code tells scripts whether there were any")
+	batch        = flag.Bool("batch", false, "read one hand per line of stdin and write one result line per hand, with no banner or prompt (default when stdin is not a terminal)")
+	format       = flag.String("format", "text", "write solutions as `text`, markdown or latex, or in batch mode also as ndjson, one JSON object per line, or csv")
+	input        = flag.String("input", "", "solve every puzzle of `FILE`, a .csv file with one hand per row or a .json array of -json-request requests, in batch mode")
+	output       = flag.String("output", "", "in batch mode, write the results to `FILE` instead of stdout, as JSON if it ends in .json")
+	workers      = flag.Int("workers", 0, "in batch mode, solve `N` hands at a time (0 means one per CPU)")
+	jsonRequest  = flag.Bool("json-request", false, "read a single JSON request from stdin, write a JSON result to stdout and exit")
+	outTemplate  = flag.String("output-template", "", "print each solution with the Go text/template in `FILE`, or each hand with the template named \"puzzle\" it defines")
+	dotDir       = flag.String("dot", "", "also write the expression tree of each solution to `DIR` as a Graphviz DOT file")
+	dotRender    = flag.String("dot-render", "", "with -dot, also render each tree in `FORMAT`, e.g. png or svg, with Graphviz's dot")
+	svgDir       = flag.String("svg", "", "also draw the expression tree of each solution as an SVG image in `DIR`, with no need for Graphviz")
+	showTree     = flag.Bool("tree", false, "draw the expression tree of each solution under it, with the value each operation makes")
+	showSteps    = flag.Bool("steps", false, "list the steps of each solution under it, one operation per line, with exact fractions")
+	table        = flag.Bool("table", false, "print solutions as a table grouped by root operator")
+	template     = flag.String("template", "", "list every hand that makes the target with the given expression shape, e.g. \"(a+b)*(c+d)\"")
+	verify       = flag.String("verify", "", "check an answer such as \"8/(3-8/3)\" for the hand given with -hand")
+	unicodeOps   = flag.Bool("unicode", false, "print ×, ÷ and √ instead of *, / and sqrt")
+	unicodeMinus = flag.Bool("unicode-minus", false, "with -unicode, also print − instead of -")
+	integerOnly  = flag.Bool("integer-only", false, "only show solutions whose intermediate values are all whole numbers")
+	nonNegative  = flag.Bool("non-negative", false, "reject solutions with a negative intermediate value, as a rule of the search")
+	maxInter     = flag.Float64("max-intermediate", 0, "reject solutions with an intermediate value beyond `N` in magnitude, e.g. 100, as a rule of the search")
+	integerDiv   = flag.Bool("integer-division", false, "only allow divisions that divide evenly, so no intermediate value is a fraction, as a rule of the search")
+	noDivision   = flag.Bool("no-division", false, "only show solutions that do not divide")
+	mustUse      = flag.String("must-use", "", "only show solutions that use every operator in `OPS`, e.g. \"*\" or \"*-\"")
+	requireOps   = flag.String("require-op", "", "like -must-use, but taken into account by the cache: only solutions using every operator in `OPS`")
+	forbidOps    = flag.String("forbid-op", "", "never use the operators in `OPS`, e.g. \"*\" to search with + - / only, or sqrt, ! and neg to turn off -sqrt, -factorial and -negation")
+	epsilon      = flag.Float64("epsilon", 0, "run the search on floating point, counting a value within `E` of the target as reaching it, e.g. 1e-9, instead of on exact fractions")
+	exact        = flag.Bool("exact", false, "run the whole search on arbitrary-precision rationals (math/big)")
+	verbose      = flag.Bool("v", false, "for each solution, also print its canonical key, the template and numbers it was built from and the formulas collapsed into it")
+	showVariants = flag.Bool("variants", false, "list the equivalent formulas merged into each unique solution")
+	allVariants  = flag.Bool("all-variants", false, "list every distinct formula of every solution as a solution of its own, e.g. both 1 + 2 and 2 + 1, with how many unique solutions they come to")
+	sortOrder    = flag.String("sort", "canonical", "order solutions by `canonical`, elegance, alpha or ops")
+	maxSolutions = flag.Int("max-solutions", 0, "stop after finding `N` solutions (0 means no limit)")
+	oneRandom    = flag.Bool("one-random", false, "print only one of the solutions, picked at random, as a hint that does not spoil the others")
+	firstOnly    = flag.Bool("first", false, "stop at the first solution; useful to check whether a hand is solvable")
+	showStats    = flag.Bool("stats", false, "report how much work each search did and how long it took")
+	timeout      = flag.Duration("timeout", 0, "give up searching a hand after `D`, e.g. 2s, and show the solutions found so far (0 means no limit)")
+	largeNumbers = flag.Bool("large-numbers", false, "accept any whole number from 1 up, not only card values 1-13")
+	negatives    = flag.Bool("negatives", false, "also accept 0 and negative numbers, e.g. -3 0 5 8")
+	fractions    = flag.Bool("allow-fractions", false, "also accept numbers such as 0.5 or 1/2")
+	decimalComma = flag.Bool("decimal-comma", false, "read a comma in a hand as a decimal point, e.g. 3,5 for 3.5, with the numbers separated by spaces or semicolons")
+	count        = flag.Int("count", 4, "how many numbers a hand has, from 2 to 8; 0 accepts any of those sizes")
+	hand         = flag.String("hand", "", "the hand a -verify answer must use, e.g. \"3 3 8 8\"")
+	configFile   = flag.String("config", "", "read the defaults of these flags from `FILE`, a TOML file with one setting per flag, e.g. target = 36 (default ~/.config/24solver/config.toml)")
+	keepHistory  = flag.Bool("keep-history", false, "keep the hands solved interactively across runs, for :history and the up arrow, in 24solver/history in the user's config directory")
+	resume       = flag.String("resume", "", "continue the interactive session or quiz saved to `FILE` with :save")
+	cacheFile    = flag.String("cache", "", "load solved hands from `FILE` at start and save them back on exit")
+	cacheSize    = flag.Int("cache-size", 1000, "remember the solutions of at most `N` hands (0 means no limit)")
 )
 
-// Node represents a node in an expression tree.
-// It can be a leaf (a number) or an internal node (an operation).
-type Node struct {
-	op    string // +, -, *, /
-	value float64
-	left  *Node
-	right *Node
+// solveRequest is the JSON object read from stdin in -json-request mode,
+// or posted to the serve command. Target defaults to -target and Ops to
+// -ops.
+type solveRequest struct {
+	Nums   []float64 `json:"nums"`
+	Target *float64  `json:"target"`
+	Ops    string    `json:"ops"`
 }
 
-// Expression represents a single valid solution found.
-type Expression struct {
-	formula string
-	value   float64
+type solveResponse struct {
+	Nums      []float64         `json:"nums"`
+	Target    float64           `json:"target"`
+	Count     int               `json:"count"`
+	Solutions []solver.Solution `json:"solutions"`
+	// Truncated is set when -timeout ended the search early, so Solutions
+	// may be incomplete.
+	Truncated bool `json:"truncated,omitempty"`
+	// Stats is only included with -stats.
+	Stats *solver.Stats `json:"stats,omitempty"`
 }
 
-var operations = []string{"+", "-", "*", "/"}
+type errorResponse struct {
+	Error string `json:"error"`
+}
 
-func calculate(a, b float64, op string) (float64, bool) {
-	switch op {
-	case "+":
-		return a + b, true
-	case "-":
-		return a - b, true
-	case "*":
-		return a * b, true
-	case "/":
-		if math.Abs(b) < 1e-9 {
-			return 0, false // Avoid division by zero.
+// runJSONRequest handles -json-request mode: it decodes one request from
+// stdin, writes one JSON result to stdout and returns the exit code.
+func runJSONRequest(opts []solver.Option) int {
+	enc := json.NewEncoder(os.Stdout)
+	fail := func(err error) int {
+		enc.Encode(errorResponse{Error: err.Error()})
+		return 1
+	}
+
+	var req solveRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		return fail(fmt.Errorf("invalid request: %v", err))
+	}
+	resp, err := answerRequest(interrupted, opts, req)
+	if err != nil {
+		return fail(err)
+	}
+	if err := enc.Encode(resp); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// answerRequest solves one request of -json-request mode or of the serve
+// command with the options of the main flags, until ctx is done.
+func answerRequest(ctx context.Context, opts []solver.Option, req solveRequest) (solveResponse, error) {
+	if req.Ops != "" {
+		opSet, err := solver.ParseOperators(req.Ops)
+		if err != nil {
+			return solveResponse{}, err
 		}
-		return a / b, true
+		opts = append(opts, solver.WithOperators(opSet...))
 	}
-	return 0, false
+	if req.Target != nil {
+		opts = append(opts, solver.WithTarget(*req.Target))
+	}
+	slv := solver.New(opts...)
+
+	solutions, stats, truncated, err := solve(ctx, slv, req.Nums)
+	if err != nil {
+		return solveResponse{}, err
+	}
+	if solutions == nil {
+		solutions = []solver.Solution{}
+	}
+	resp := solveResponse{Nums: req.Nums, Target: slv.Target(), Count: len(solutions), Solutions: solutions, Truncated: truncated}
+	if *showStats {
+		resp.Stats = &stats
+	}
+	return resp, nil
 }
 
-func isApproximately24(value float64) bool {
-	return math.Abs(value-24.0) < 1e-9
+// session is what the REPL and the solve command need to solve a hand
+// the way the flags ask.
+type session struct {
+	slv     *solver.Solver
+	targets []float64
+	order   solver.SortOrder
+	limit   int
+	// reachLow and reachHigh are the bounds of -reachable, when set.
+	reachLow, reachHigh int
+	// tmpl is the -output-template, when set.
+	tmpl *texttemplate.Template
+	// opts are the options slv was created with, which the commands of
+	// the interactive loop add to.
+	opts []solver.Option
+	// last is the hand the interactive loop solved last, hands all those
+	// it has solved in order, and stats counts them.
+	last  []float64
+	hands [][]float64
+	stats replStats
+	// changed holds the settings the commands of the interactive loop
+	// have changed, for :save.
+	changed savedSettings
+	// historyFile is where the hands solved are added with -keep-history.
+	historyFile string
 }
 
-func generatePermutations(nums []float64) [][]float64 {
-	if len(nums) <= 1 {
-		return [][]float64{nums}
+// solveHand prints the solutions of nums, or with -reachable the whole
+// numbers they make, and reports whether it found any.
+func (sess *session) solveHand(nums []float64) bool {
+	slv := sess.slv
+	if *reachable != "" {
+		solutions, err := printReachable(slv, nums, sess.reachLow, sess.reachHigh)
+		if err != nil {
+			errorf("%s", err)
+		}
+		return len(solutions) > 0
 	}
-	var result [][]float64
-	for i, num := range nums {
-		remaining := make([]float64, 0, len(nums)-1)
-		remaining = append(remaining, nums[:i]...)
-		remaining = append(remaining, nums[i+1:]...)
-		for _, perm := range generatePermutations(remaining) {
-			newPerm := append([]float64{num}, perm...)
-			result = append(result, newPerm)
+	if len(sess.targets) > 1 {
+		return sess.printTargets(nums)
+	}
+
+	var (
+		solutions []solver.Solution
+		stats     solver.Stats
+		truncated bool
+		err       error
+	)
+	// With -first only whether the hand is solvable matters, which the
+	// table behind Solver.Lookup answers for the standard hands.
+	if !*firstOnly || *showStats || !knownUnsolvable(slv, nums) {
+		solutions, stats, truncated, err = solve(interrupted, slv, nums)
+		searchProgress.clear()
+	}
+	if err != nil {
+		errorf("%s", err)
+		return false
+	}
+	solver.Sort(solutions, sess.order)
+	if sess.tmpl != nil {
+		sess.printTemplate(nums, slv.Target(), solutions, truncated)
+		return len(solutions) > 0
+	}
+	if *quiet {
+		printSolutions(slv, nums, solutions, sess.limit)
+		if *showStats {
+			printStats(stats)
+		}
+		return len(solutions) > 0
+	}
+	if truncated {
+		printTruncated()
+	}
+	if len(solutions) == 0 {
+		printf("No solutions found for these numbers.\n")
+		if !truncated {
+			printClosest(slv, nums)
 		}
+	} else {
+		printSolutions(slv, nums, solutions, sess.limit)
+	}
+	if *showStats {
+		blankLine()
+		printStats(stats)
+	}
+	return len(solutions) > 0
+}
+
+// solve searches nums within -timeout, until ctx is done, which is
+// interrupted unless the search answers an HTTP request. When the time
+// runs out, or Ctrl-C is pressed, it returns the solutions found so far
+// with truncated set instead of an error.
+func solve(ctx context.Context, slv *solver.Solver, nums []float64) (solutions []solver.Solution, stats solver.Stats, truncated bool, err error) {
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	solutions, stats, err = slv.SolveStats(ctx, nums)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return solutions, stats, true, nil
+	}
+	return solutions, stats, false, err
+}
+
+// knownUnsolvable reports whether slv.Lookup answers nums, a standard
+// hand, as unsolvable, so no search is needed to find that out.
+func knownUnsolvable(slv *solver.Solver, nums []float64) bool {
+	_, solvable, ok := slv.Lookup(nums)
+	return ok && !solvable
+}
+
+// solveTargets is solve for several targets at once, see
+// Solver.SolveTargets.
+func solveTargets(slv *solver.Solver, nums, targets []float64) (results map[float64][]solver.Solution, truncated bool, err error) {
+	ctx := interrupted
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	results, err = slv.SolveTargetsContext(ctx, nums, targets)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return results, true, nil
+	}
+	return results, false, err
+}
+
+// printTruncated says that a search was cut short by -timeout or Ctrl-C.
+func printTruncated() {
+	if isInterrupted() {
+		printf("Search interrupted; the solutions below may be incomplete.\n\n")
+		return
 	}
-	return result
+	printf("Search truncated after %s; the solutions below may be incomplete.\n\n", *timeout)
 }
 
-func generateOperations() [][]string {
-	var result [][]string
-	for _, op1 := range operations {
-		for _, op2 := range operations {
-			for _, op3 := range operations {
-				result = append(result, []string{op1, op2, op3})
+// printSolutions prints the solutions found for nums, which are not
+// empty, as a list, with -table as a table or in the Markdown or LaTeX of
+// -format, following a line saying how many there are. With -quiet there
+// is no such line, the list is a plain line per solution and solutions
+// may be empty, printing nothing. With -one-random only one of the
+// solutions is printed, picked at random, and with -all-variants every
+// formula of each, see expandVariants.
+func printSolutions(slv *solver.Solver, nums []float64, solutions []solver.Solution, limit int) {
+	total := len(solutions)
+	if *oneRandom && total > 1 {
+		i := rand.IntN(total)
+		solutions = solutions[i : i+1]
+	}
+	if *allVariants {
+		solutions = expandVariants(solutions)
+	}
+	if (*dotDir != "" || *svgDir != "") && len(solutions) > 0 {
+		defer func() {
+			if err := writeDiagrams(nums, solutions); err != nil {
+				errorf("%s", err)
 			}
+		}()
+	}
+	switch {
+	case *quiet:
+		if len(solutions) == 0 {
+			return
+		}
+	case *allVariants && !*oneRandom:
+		printf("Found %d solution(s) as written, %d of them unique:\n\n", len(solutions), total)
+	case *oneRandom:
+		printf("One of %d unique solution(s), picked at random:\n\n", total)
+	case limit > 0 && len(solutions) == limit:
+		printf("Showing the first %d unique solution(s) found:\n\n", len(solutions))
+	case *showVariants:
+		printf("Found %d unique solution(s), %d variant(s):\n\n", len(solutions), countVariants(solutions))
+	default:
+		printf("Found %d unique solution(s):\n\n", len(solutions))
+	}
+	switch {
+	case *table && !*plain:
+		printTable(solutions, slv.Operators())
+		return
+	case *format == "markdown":
+		printMarkdown(solutions, nums)
+		return
+	case *format == "latex":
+		printLaTeX(solutions, nums)
+		return
+	}
+	best := mostElegant(solutions)
+	for i, solution := range solutions {
+		line := colorSolution(solution.Formula, solution.Value, i == best) + numbersUsed(solution, nums)
+		if *quiet {
+			fmt.Println(line)
+			continue
+		}
+		fmt.Printf("%d. %s\n", i+1, line)
+		if *showTree {
+			printTree(solution)
+		}
+		if *showSteps {
+			printSteps(solution)
+		}
+		if *verbose {
+			printDetails(solution)
+			continue
+		}
+		if len(solution.Variants) > 1 {
+			for _, variant := range solution.Variants[1:] {
+				printf("     same as %s\n", displayFormula(variant))
+			}
+		}
+	}
+}
+
+// printTree prints what -tree shows of a solution, its tree, indented
+// under it.
+func printTree(solution solver.Solution) {
+	tree := strings.TrimSuffix(solution.Tree.TextTree(), "\n")
+	for _, line := range strings.Split(tree, "\n") {
+		fmt.Println("   " + line)
+	}
+}
+
+// printSteps prints what -steps shows of a solution, its derivation,
+// numbered and indented under it.
+func printSteps(solution solver.Solution) {
+	for i, step := range solution.Tree.Derivation() {
+		printf("   Step %d: %s\n", i+1, displayFormula(step))
+	}
+}
+
+// expandVariants returns every distinct formula of solutions, which were
+// found with their Variants, as a solution of its own with the value, key
+// and tree of the unique solution it was merged into.
+func expandVariants(solutions []solver.Solution) []solver.Solution {
+	var expanded []solver.Solution
+	for _, solution := range solutions {
+		formulas := solution.Variants
+		if len(formulas) == 0 {
+			formulas = []string{solution.Formula}
+		}
+		for _, formula := range formulas {
+			variant := solution
+			variant.Formula, variant.Variants = formula, nil
+			expanded = append(expanded, variant)
 		}
 	}
-	return result
+	return expanded
 }
 
-// numStr is a helper to convert a float to a string for keys.
-func numStr(n float64) string {
-	return strconv.FormatFloat(n, 'g', -1, 64)
+// printDetails prints what -v shows of a solution: its canonical key, the
+// template of its expression with the numbers in the order they fill it,
+// and every other formula found for it, which were collapsed into it as
+// duplicates.
+func printDetails(solution solver.Solution) {
+	printf("     key: %s\n", solution.Key)
+	leaves := solution.Tree.Leaves()
+	fills := make([]string, len(leaves))
+	for i, leaf := range leaves {
+		fills[i] = fmt.Sprintf("%c=%s", 'a'+i, formatNumber(leaf))
+	}
+	printf("     from: %s with %s\n", solution.Tree.Template(), strings.Join(fills, ", "))
+	if len(solution.Variants) <= 1 {
+		printf("     collapsed: none\n")
+		return
+	}
+	printf("     collapsed: %d duplicate(s)\n", len(solution.Variants)-1)
+	for _, variant := range solution.Variants[1:] {
+		fmt.Print(plainText(fmt.Sprintf("       %s\n", displayFormula(variant))))
+	}
 }
 
-// collectOperands traverses chains of the same associative operator (like a + b + c)
-// to flatten the structure for normalization.
-func collectOperands(node *Node, op string, operands *[]string) {
-	// If the child node is part of the same associative chain, recurse.
-	if node.op == op {
-		if node.left != nil {
-			collectOperands(node.left, op, operands)
+// printTargets solves nums for every target of the session in one search
+// and prints the solutions of each target in turn. It reports whether any
+// target was reached.
+func (sess *session) printTargets(nums []float64) bool {
+	results, truncated, err := solveTargets(sess.slv, nums, sess.targets)
+	searchProgress.clear()
+	if err != nil {
+		errorf("%s", err)
+		return false
+	}
+	found := false
+	if sess.tmpl != nil {
+		for _, target := range sess.targets {
+			solutions := results[target]
+			solver.Sort(solutions, sess.order)
+			sess.printTemplate(nums, target, solutions, truncated)
+			found = found || len(solutions) > 0
 		}
-		if node.right != nil {
-			collectOperands(node.right, op, operands)
+		return found
+	}
+	if *quiet {
+		for _, target := range sess.targets {
+			solutions := results[target]
+			solver.Sort(solutions, sess.order)
+			printSolutions(sess.slv, nums, solutions, sess.limit)
+			found = found || len(solutions) > 0
+		}
+		return found
+	}
+	if truncated {
+		printTruncated()
+	}
+	for i, target := range sess.targets {
+		if i > 0 {
+			blankLine()
+		}
+		printf("Target %s: ", paint(formatNumber(target), colorYellow))
+		solutions := results[target]
+		if len(solutions) == 0 {
+			printf("no solutions found.\n")
+			continue
+		}
+		found = true
+		solver.Sort(solutions, sess.order)
+		printSolutions(sess.slv, nums, solutions, sess.limit)
+	}
+	return found
+}
+
+// numbersUsed describes how many numbers solution uses, for -subsets and
+// -reuse, e.g. " (3 of 4 numbers)". It is empty when every number is used
+// once.
+func numbersUsed(solution solver.Solution, nums []float64) string {
+	switch {
+	case *reuse > 1:
+		return sprintf(" (%d numbers)", solution.Meta.Numbers)
+	case *subsets:
+		return sprintf(" (%d of %d numbers)", solution.Meta.Numbers, len(nums))
+	}
+	return ""
+}
+
+// printClosest prints the expressions that come closest to the target of
+// slv, for a hand that cannot reach it.
+func printClosest(slv *solver.Solver, nums []float64) {
+	closest, distance, err := slv.Closest(nums)
+	if err != nil || len(closest) == 0 {
+		return
+	}
+	printf("The closest you can get is %s away:\n\n", formatNumber(distance))
+	for i, solution := range closest {
+		fmt.Printf("%d. %s\n", i+1, colorSolution(solution.Formula, solution.Value, false))
+	}
+}
+
+// mostElegant returns the index of the first of the solutions with the
+// highest Elegance, which is highlighted when output is colored, or -1
+// when output is not colored or there is only one solution.
+func mostElegant(solutions []solver.Solution) int {
+	if !colorful() || len(solutions) < 2 {
+		return -1
+	}
+	best := 0
+	for i, solution := range solutions {
+		if solution.Elegance() > solutions[best].Elegance() {
+			best = i
 		}
-	} else {
-		// Otherwise, it's a new sub-expression, get its key.
-		*operands = append(*operands, getCanonicalKey(node))
 	}
+	return best
 }
 
-// getCanonicalKey generates a unique, normalized string representation from an expression tree.
-// This key ignores differences in operator order (commutativity) and grouping (associativity).
-func getCanonicalKey(node *Node) string {
-	// Base case: leaf node (a number)
-	if node.left == nil && node.right == nil {
-		return numStr(node.value)
+// printStats prints the -stats line for one search.
+func printStats(stats solver.Stats) {
+	if stats.Cached {
+		printf("Stats: answered from the cache in %s\n", stats.Elapsed)
+		return
 	}
+	printf("Stats: %d evaluated, %d pruned, %d division(s) by zero skipped, %d duplicate(s) collapsed, %s\n",
+		stats.Evaluated, stats.Pruned, stats.DivByZero, stats.Duplicates, stats.Elapsed)
+}
 
-	// Recursive step: get keys for children
-	keyL := getCanonicalKey(node.left)
-	keyR := getCanonicalKey(node.right)
+// runTemplate prints every hand of digits 1-9 that reaches target when its
+// digits are assigned, in some order, to the placeholders of the template.
+func runTemplate(input string, target float64) int {
+	hands, err := solver.SolveTemplate(input, target)
+	if err != nil {
+		errorf("%s", err)
+		return 1
+	}
+	if len(hands) == 0 {
+		printf("No hands make %s with %s.\n", formatNumber(target), input)
+		return 0
+	}
+	printf("Found %d hand(s) that make %s with %s:\n\n", len(hands), formatNumber(target), input)
+	for i, hand := range hands {
+		n := hand.Numbers
+		fmt.Printf("%d. %.0f %.0f %.0f %.0f: %s = %s\n", i+1, n[0], n[1], n[2], n[3], displayFormula(hand.Formula), formatNumber(target))
+	}
+	return 0
+}
 
-	// --- Normalization Rules ---
+// runVerify checks a user-written answer for a hand and reports whether it
+// is correct through the exit code.
+func runVerify(slv *solver.Solver, answer, handInput string) int {
+	nums, err := slv.ParseHandN(handInput, *count)
+	if err != nil {
+		errorf("invalid hand: %s", err)
+		return 1
+	}
+	if err := slv.Verify(answer, nums); err != nil {
+		printf("Incorrect: %s\n", err)
+		return 1
+	}
+	if slv.Tolerance() > 0 {
+		printf("Correct! %s makes %s\n", answer, describeTarget(slv))
+		return 0
+	}
+	printf("Correct! %s = %s\n", answer, formatNumber(slv.Target()))
+	return 0
+}
 
-	// 1. Identity operations: simplify expressions with *1 or /1.
-	if node.op == "*" {
-		if keyL == "1" { return keyR }
-		if keyR == "1" { return keyL }
+// runVerifyCommand handles the verify command, the answer followed by the
+// hand, e.g. verify "8/(3-8/3)" 3 3 8 8. Without a hand, -hand is used.
+func runVerifyCommand(slv *solver.Solver, args []string) int {
+	if len(args) == 0 {
+		errorf("give the answer and the hand, e.g. verify \"8/(3-8/3)\" 3 3 8 8")
+		return 2
 	}
-	if node.op == "/" && keyR == "1" {
-		return keyL
+	handInput := *hand
+	if len(args) > 1 {
+		handInput = strings.Join(args[1:], " ")
 	}
+	return runVerify(slv, args[0], handInput)
+}
 
-	// 2. Associativity & Commutativity: for + and *, flatten the expression,
-	// sort the operands, and join them. This treats (a+b)+c and c+(a+b) as identical.
-	if node.op == "+" || node.op == "*" {
-		operands := []string{}
-		collectOperands(node, node.op, &operands)
-		sort.Strings(operands) // Sort for commutativity.
-		return "(" + strings.Join(operands, node.op) + ")"
+// loadCache returns the cache of solved hands, filled from -cache when that
+// file exists.
+func loadCache() (*solver.Cache, error) {
+	cache := solver.NewCache(*cacheSize)
+	if *cacheFile == "" {
+		return cache, nil
+	}
+	f, err := os.Open(*cacheFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
 	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := cache.Load(f); err != nil {
+		return nil, fmt.Errorf("%s: %v", *cacheFile, err)
+	}
+	return cache, nil
+}
 
-	// 3. For non-commutative/associative operations (-, /), the order matters.
-	return "(" + keyL + node.op + keyR + ")"
+// saveCache writes the cache back to -cache, if it was given.
+func saveCache(cache *solver.Cache) error {
+	if *cacheFile == "" {
+		return nil
+	}
+	f, err := os.Create(*cacheFile)
+	if err != nil {
+		return err
+	}
+	if err := cache.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// formatNumber prints a value without trailing zeros, rounding away the
+// error a float search can leave, e.g. 24, 2.5 or 0.333333333.
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e9)/1e9, 'f', -1, 64)
 }
 
-// findSolutions builds expression trees for all 5 parenthesis patterns,
-// then generates a canonical key to find truly unique solutions.
-func findSolutions(perm []float64, ops []string, seenKeys map[string]bool) []Expression {
-	var results []Expression
-	n := make([]*Node, 4)
-	for i := 0; i < 4; i++ {
-		n[i] = &Node{value: perm[i]}
+// describeTarget says what solutions of slv must make: the target, or the
+// range of values a tolerance allows.
+func describeTarget(slv *solver.Solver) string {
+	if slv.Tolerance() == 0 {
+		return formatNumber(slv.Target())
 	}
-	op := ops
+	return sprintf("a value from %s to %s", formatNumber(slv.Target()-slv.Tolerance()), formatNumber(slv.Target()+slv.Tolerance()))
+}
 
-	formulas := map[int]string{
-		1: "((%.0f %s %.0f) %s %.0f) %s %.0f",
-		2: "(%.0f %s (%.0f %s %.0f)) %s %.0f",
-		3: "%.0f %s ((%.0f %s %.0f) %s %.0f)",
-		4: "%.0f %s (%.0f %s (%.0f %s %.0f))",
-		5: "(%.0f %s %.0f) %s (%.0f %s %.0f)",
+// describeTargets is describeTarget for every one of targets.
+func describeTargets(slv *solver.Solver, targets []float64) string {
+	if len(targets) == 1 {
+		return describeTarget(slv)
+	}
+	described := make([]string, len(targets))
+	for i, target := range targets {
+		described[i] = formatNumber(target)
 	}
-	var trees []*Node
+	return sprintf("%s and %s", strings.Join(described[:len(described)-1], ", "), described[len(described)-1])
+}
 
-	// Pattern 1: ((a op b) op c) op d
-	if v1, ok := calculate(n[0].value, n[1].value, op[0]); ok {
-		if v2, ok := calculate(v1, n[2].value, op[1]); ok {
-			if v3, ok := calculate(v2, n[3].value, op[2]); ok && isApproximately24(v3) {
-				node1 := &Node{op: op[0], value: v1, left: n[0], right: n[1]}
-				node2 := &Node{op: op[1], value: v2, left: node1, right: n[2]}
-				trees = append(trees, &Node{op: op[2], value: v3, left: node2, right: n[3]})
-			}
+// parseTargets reads -target, one value or several separated by commas,
+// e.g. "24,36,100".
+func parseTargets(s string) ([]float64, error) {
+	var targets []float64
+	for _, field := range strings.Split(s, ",") {
+		target, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, newError("invalid target %q, want a number or several separated by commas such as 24,36,100", strings.TrimSpace(field))
 		}
+		targets = append(targets, target)
 	}
-	// Pattern 2: (a op (b op c)) op d
-	if v1, ok := calculate(n[1].value, n[2].value, op[1]); ok {
-		if v2, ok := calculate(n[0].value, v1, op[0]); ok {
-			if v3, ok := calculate(v2, n[3].value, op[2]); ok && isApproximately24(v3) {
-				node1 := &Node{op: op[1], value: v1, left: n[1], right: n[2]}
-				node2 := &Node{op: op[0], value: v2, left: n[0], right: node1}
-				trees = append(trees, &Node{op: op[2], value: v3, left: node2, right: n[3]})
-			}
-		}
+	return targets, nil
+}
+
+// parseRange reads a range such as "20..30", for -target-range and
+// -reachable.
+func parseRange(s string) (low, high float64, err error) {
+	lowText, highText, ok := strings.Cut(s, "..")
+	if ok {
+		low, err = strconv.ParseFloat(strings.TrimSpace(lowText), 64)
 	}
-	// Pattern 3: a op ((b op c) op d)
-	if v1, ok := calculate(n[1].value, n[2].value, op[1]); ok {
-		if v2, ok := calculate(v1, n[3].value, op[2]); ok {
-			if v3, ok := calculate(n[0].value, v2, op[0]); ok && isApproximately24(v3) {
-				node1 := &Node{op: op[1], value: v1, left: n[1], right: n[2]}
-				node2 := &Node{op: op[2], value: v2, left: node1, right: n[3]}
-				trees = append(trees, &Node{op: op[0], value: v3, left: n[0], right: node2})
-			}
+	if ok && err == nil {
+		high, err = strconv.ParseFloat(strings.TrimSpace(highText), 64)
+	}
+	if !ok || err != nil || low > high {
+		return 0, 0, newError("invalid range %q, want LOW..HIGH such as 20..30", s)
+	}
+	return low, high, nil
+}
+
+// describeCount says how many numbers to enter, following -count.
+func describeCount() string {
+	if *count == 0 {
+		return sprintf("%d to %d numbers", solver.MinNumbers, solver.MaxNumbers)
+	}
+	return sprintf("%d numbers", *count)
+}
+
+// describeNumbers says which numbers a hand may hold.
+func describeNumbers() string {
+	switch {
+	case *largeNumbers && *negatives:
+		return sprintf("any whole number")
+	case *largeNumbers:
+		return sprintf("any whole number from 1")
+	case *negatives:
+		return sprintf("-13 to 13")
+	}
+	return "1-13"
+}
+
+// formatHand lists the numbers of a hand, e.g. "3, 3, 8, 8".
+func formatHand(nums []float64) string {
+	parts := make([]string, len(nums))
+	for i, num := range nums {
+		parts[i] = formatNumber(num)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// displayOp returns the symbol to print for op, honoring -unicode.
+func displayOp(op string) string {
+	if !*unicodeOps {
+		return op
+	}
+	switch op {
+	case "*":
+		return "×"
+	case "/":
+		return "÷"
+	case "-":
+		if *unicodeMinus {
+			return "−"
 		}
 	}
-	// Pattern 4: a op (b op (c op d))
-	if v1, ok := calculate(n[2].value, n[3].value, op[2]); ok {
-		if v2, ok := calculate(n[1].value, v1, op[1]); ok {
-			if v3, ok := calculate(n[0].value, v2, op[0]); ok && isApproximately24(v3) {
-				node1 := &Node{op: op[2], value: v1, left: n[2], right: n[3]}
-				node2 := &Node{op: op[1], value: v2, left: n[1], right: node1}
-				trees = append(trees, &Node{op: op[0], value: v3, left: n[0], right: node2})
+	return op
+}
+
+// displayFormula rewrites the operators of a formula for printing. Only
+// operators surrounded by spaces are touched, so signs of numbers are kept.
+func displayFormula(formula string) string {
+	if !*unicodeOps {
+		return formula
+	}
+	for _, op := range []string{"*", "/", "-"} {
+		formula = strings.ReplaceAll(formula, " "+op+" ", " "+displayOp(op)+" ")
+	}
+	return strings.ReplaceAll(formula, "sqrt(", "√(")
+}
+
+// countVariants returns how many formulas were merged into solutions,
+// counting each solution's own formula.
+func countVariants(solutions []solver.Solution) int {
+	total := 0
+	for _, solution := range solutions {
+		total += len(solution.Variants)
+	}
+	return total
+}
+
+// isTerminal reports whether f is attached to a terminal rather than a
+// pipe or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printTable prints solutions grouped by the operator at the root of their
+// expression tree, i.e. the last operation performed: the binary operators
+// in search order, then sqrt, ! and neg, then a solution that is a single
+// number, as -subsets can find. On a terminal the columns are aligned;
+// when piped it prints plain tab-separated rows.
+func printTable(solutions []solver.Solution, operators []string) {
+	groups := make(map[string][]solver.Solution)
+	for _, solution := range solutions {
+		groups[solution.RootOperator()] = append(groups[solution.RootOperator()], solution)
+	}
+	operators = append(slices.Clone(operators), "sqrt", "!", "neg", "")
+
+	if !isTerminal(os.Stdout) {
+		for _, op := range operators {
+			for _, solution := range groups[op] {
+				fmt.Printf("%s\t%s = %s\n", rootLabel(op), displayFormula(solution.Formula), formatNumber(solution.Value))
 			}
 		}
+		return
 	}
-	// Pattern 5: (a op b) op (c op d)
-	if v1, ok1 := calculate(n[0].value, n[1].value, op[0]); ok1 {
-		if v2, ok2 := calculate(n[2].value, n[3].value, op[2]); ok2 {
-			if v3, ok3 := calculate(v1, v2, op[1]); ok3 && isApproximately24(v3) {
-				node1 := &Node{op: op[0], value: v1, left: n[0], right: n[1]}
-				node2 := &Node{op: op[2], value: v2, left: n[2], right: n[3]}
-				trees = append(trees, &Node{op: op[1], value: v3, left: node1, right: node2})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, translate("ROOT\tCOUNT\tSOLUTION"))
+	for _, op := range operators {
+		for i, solution := range groups[op] {
+			if i == 0 {
+				fmt.Fprintf(w, "%s\t%d\t%s = %s\n", rootLabel(op), len(groups[op]), displayFormula(solution.Formula), formatNumber(solution.Value))
+			} else {
+				fmt.Fprintf(w, "\t\t%s = %s\n", displayFormula(solution.Formula), formatNumber(solution.Value))
 			}
 		}
 	}
+	w.Flush()
+}
 
-	for i, tree := range trees {
-		key := getCanonicalKey(tree)
-		if !seenKeys[key] {
-			seenKeys[key] = true
-			formula := fmt.Sprintf(formulas[i+1], perm[0], ops[0], perm[1], ops[1], perm[2], ops[2], perm[3])
-			results = append(results, Expression{formula: formula, value: tree.value})
-		}
+// rootLabel is how printTable shows the root operator op, which is empty
+// for a single number.
+func rootLabel(op string) string {
+	if op == "" {
+		return translate("number")
 	}
-	return results
+	return displayOp(op)
 }
 
-func parseInput(input string) ([]float64, error) {
-	input = strings.TrimSpace(input)
-	var parts []string
-	if strings.Contains(input, ",") {
-		parts = strings.Split(input, ",")
-	} else if strings.Contains(input, " ") {
-		parts = strings.Fields(input)
-	} else if len(input) == 4 {
-		parts = make([]string, 4)
-		for i, char := range input {
-			if char < '0' || char > '9' {
-				return nil, fmt.Errorf("input must be numeric if no spaces/commas are used")
-			}
-			parts[i] = string(char)
+// formats lists the values of -format.
+var formats = []string{"text", "markdown", "latex", "ndjson", "csv"}
+
+// commands lists the commands of the CLI for -h, each with what it does.
+// Without a command, the REPL starts, unless a hand is given.
+var commands = [][2]string{
+	{"solve HAND", "solve one hand, e.g. solve 3 3 8 8, and exit"},
+	{"verify ANSWER HAND", "check an answer, e.g. verify \"8/(3-8/3)\" 3 3 8 8"},
+	{"gen", "deal random solvable hands, e.g. gen -difficulty hard -n 5"},
+	{"quiz", "deal hands and check the answers typed for them"},
+	{"serve", "answer JSON requests over HTTP, e.g. serve -port 8080"},
+	{"bench", "time the search over the standard hands"},
+	{"countdown", "play a round of the Countdown numbers game"},
+	{"krypto", "solve a hand of Krypto"},
+	{"fours", "list the numbers four 4s can make"},
+	{"worksheet", "write a printable sheet of puzzles with an answer key, e.g. worksheet -n 20 -o sheet.html"},
+	{"completion SHELL", "write the completion script of bash, zsh or fish"},
+}
+
+// usage prints the -h help: the commands, then the flags, which go before
+// the command.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command [arguments] | hand]\n\nWith a hand, e.g. 3 3 8 8, it is solved as with solve. Without a command\nor a hand, hands are read and solved interactively.\n\nCommands:\n", os.Args[0])
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, command := range commands {
+		fmt.Fprintf(w, "  %s\t%s\n", command[0], command[1])
+	}
+	w.Flush()
+	fmt.Fprintf(out, "\nRun a command with -h for its own flags.\n\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if err := loadSettings(); err != nil {
+		errorf("%s", err)
+		os.Exit(2)
+	}
+	if err := setLanguage(*lang); err != nil {
+		errorf("%s", err)
+		os.Exit(2)
+	}
+	if *count != 0 && (*count < solver.MinNumbers || *count > solver.MaxNumbers) {
+		errorf("-count must be 0 or from %d to %d", solver.MinNumbers, solver.MaxNumbers)
+		os.Exit(2)
+	}
+
+	order, err := solver.ParseSortOrder(*sortOrder)
+	if err != nil {
+		errorf("%s", err)
+		os.Exit(2)
+	}
+	dedupLevel, err := solver.ParseDedup(*dedup)
+	if err != nil {
+		errorf("%s", err)
+		os.Exit(2)
+	}
+
+	if !slices.Contains(formats, *format) {
+		errorf("unknown format '%s', want one of %s", *format, strings.Join(formats, ", "))
+		os.Exit(2)
+	}
+
+	opSet, err := solver.ParseOperators(*ops)
+	if err != nil {
+		errorf("%s", err)
+		os.Exit(2)
+	}
+
+	targets, err := parseTargets(*target)
+	if err != nil {
+		errorf("%s", err)
+		os.Exit(2)
+	}
+	if len(targets) > 1 && (*targetRange != "" || *tolerance > 0) {
+		errorf("several targets cannot be combined with -tolerance or -target-range")
+		os.Exit(2)
+	}
+
+	limit := *maxSolutions
+	if *firstOnly {
+		limit = 1
+	}
+	opts := []solver.Option{
+		solver.WithTarget(targets[0]),
+		solver.WithOperators(opSet...),
+		solver.WithSqrt(*sqrtDepth),
+		solver.WithFactorial(*factorials),
+		solver.WithNegation(*negation),
+		solver.WithConcatenation(*concat),
+		solver.WithSubsets(*subsets),
+		solver.WithReuse(*reuse),
+		solver.WithIntegerDivision(*integerDiv),
+		solver.WithNonNegative(*nonNegative),
+		solver.WithMaxIntermediate(*maxInter),
+		solver.WithTolerance(*tolerance),
+		solver.WithLargeNumbers(*largeNumbers),
+		solver.WithZeroAndNegatives(*negatives),
+		solver.WithFractions(*fractions),
+		solver.WithDecimalComma(*decimalComma),
+		solver.WithMergeMirrors(*mergeMirrors),
+		solver.WithDedup(dedupLevel),
+		solver.WithMaxSolutions(limit),
+		solver.WithVariants(*showVariants || *allVariants || *verbose),
+	}
+	if *targetRange != "" {
+		low, high, err := parseRange(*targetRange)
+		if err != nil {
+			errorf("%s", err)
+			os.Exit(2)
 		}
-	} else {
-		parts = strings.Fields(input)
+		opts = append(opts, solver.WithTargetRange(low, high))
+	}
+	if *epsilon > 0 {
+		opts = append(opts, solver.WithEpsilon(*epsilon))
+	}
+	if *exact {
+		opts = append(opts, solver.WithArithmetic[*big.Rat](solver.RatArithmetic{}))
 	}
-	if len(parts) != 4 {
-		return nil, fmt.Errorf("you must enter exactly 4 numbers")
+	if *integerOnly {
+		opts = append(opts, solver.WithFilters(solver.IntegerOnly))
 	}
-	var nums []float64
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		num, err := strconv.ParseFloat(part, 64)
+	if *noDivision {
+		opts = append(opts, solver.WithFilters(solver.NoDivision))
+	}
+	if *mustUse != "" {
+		required, err := solver.ParseOperators(*mustUse)
 		if err != nil {
-			return nil, fmt.Errorf("'%s' is not a valid number", part)
+			errorf("%s", err)
+			os.Exit(2)
 		}
-		if num < 1 || num > 9 || num != math.Floor(num) {
-			return nil, fmt.Errorf("numbers must be digits 1-9, found: %g", num)
+		opts = append(opts, solver.WithFilters(solver.MustUse(required...)))
+	}
+	if *requireOps != "" {
+		required, err := solver.ParseOperators(*requireOps)
+		if err != nil {
+			errorf("%s", err)
+			os.Exit(2)
 		}
-		nums = append(nums, num)
+		opts = append(opts, solver.WithRequiredOperators(required...))
 	}
-	return nums, nil
-}
+	if *forbidOps != "" {
+		forbidden, err := solver.ParseForbiddenOperators(*forbidOps)
+		if err != nil {
+			errorf("%s", err)
+			os.Exit(2)
+		}
+		opts = append(opts, solver.WithForbiddenOperators(forbidden...))
+		if len(solver.New(opts...).Operators()) == 0 {
+			errorf("-forbid-op %q leaves no operators to combine the numbers with", *forbidOps)
+			os.Exit(2)
+		}
+	}
+	cache, err := loadCache()
+	if err != nil {
+		errorf("cannot load cache: %s", err)
+		os.Exit(2)
+	}
+	opts = append(opts, solver.WithCache(cache))
 
-func main() {
-	fmt.Println("WELCOME TO THE 24 GAME SOLVER")
-	fmt.Println("===============================")
-	fmt.Println("Rules:")
-	fmt.Println("- Enter 4 numbers (digits 1-9)")
-	fmt.Println("- Format: 1 2 3 4 or 1,2,3,4 or 1234")
-	fmt.Println("- The program will find all unique ways to make 24.")
-	fmt.Println("- Supports: +, -, *, /")
-	fmt.Println("===============================")
-
-	scanner := bufio.NewScanner(os.Stdin)
+	var reachLow, reachHigh int
+	if *reachable != "" {
+		low, high, err := parseRange(*reachable)
+		if err == nil && (low != math.Trunc(low) || high != math.Trunc(high)) {
+			err = newError("invalid range %q, want whole numbers such as 1..100", *reachable)
+		}
+		if err != nil {
+			errorf("%s", err)
+			os.Exit(2)
+		}
+		reachLow, reachHigh = int(low), int(high)
+	}
+	var tmpl *texttemplate.Template
+	if *outTemplate != "" {
+		if tmpl, err = loadOutputTemplate(*outTemplate); err != nil {
+			errorf("%s", err)
+			os.Exit(2)
+		}
+	}
+	// Only the searches of the session show their progress: batch mode and
+	// the commands solve with opts.
+	sessOpts := slices.Concat(opts, progressOptions())
+	sess := &session{
+		slv:       solver.New(sessOpts...),
+		targets:   targets,
+		order:     order,
+		limit:     limit,
+		reachLow:  reachLow,
+		reachHigh: reachHigh,
+		tmpl:      tmpl,
+		opts:      sessOpts,
+	}
+
+	args := flag.Args()
+	switch flag.Arg(0) {
+	case "":
+	case "solve":
+		handleInterrupt()
+		code := runSolve(sess, args[1:])
+		if err := saveCache(cache); err != nil {
+			errorf("cannot save cache: %s", err)
+		}
+		os.Exit(code)
+	case "verify":
+		os.Exit(runVerifyCommand(sess.slv, args[1:]))
+	case "gen":
+		os.Exit(runGen(opts, args[1:]))
+	case "quiz":
+		handleInterrupt()
+		os.Exit(runQuiz(opts, args[1:]))
+	case "serve":
+		os.Exit(runServe(opts, args[1:]))
+	case "bench":
+		os.Exit(runBench(opts, args[1:]))
+	case "countdown":
+		os.Exit(runCountdown(opts, args[1:]))
+	case "krypto":
+		os.Exit(runKrypto(opts, args[1:]))
+	case "fours":
+		os.Exit(runFours(opts, args[1:]))
+	case "worksheet":
+		os.Exit(runWorksheet(opts, args[1:]))
+	case "completion":
+		os.Exit(runCompletion(args[1:]))
+	default:
+		if _, err := sess.slv.ParseHandN(strings.Join(args, " "), *count); err != nil && isCommandLike(flag.Arg(0)) {
+			errorf("unknown command '%s'; run with -h for the list of commands", flag.Arg(0))
+			os.Exit(2)
+		}
+		handleInterrupt()
+		code := runSolve(sess, args)
+		if err := saveCache(cache); err != nil {
+			errorf("cannot save cache: %s", err)
+		}
+		os.Exit(code)
+	}
+	if *jsonRequest {
+		code := runJSONRequest(opts)
+		if err := saveCache(cache); err != nil {
+			errorf("cannot save cache: %s", err)
+		}
+		os.Exit(code)
+	}
+	if *template != "" {
+		os.Exit(runTemplate(*template, targets[0]))
+	}
+	if *verify != "" {
+		os.Exit(runVerify(sess.slv, *verify, *hand))
+	}
+	handleInterrupt()
+	if batchMode() {
+		code := runBatch(sess, opts, os.Stdin, os.Stdout)
+		if err := saveCache(cache); err != nil {
+			errorf("cannot save cache: %s", err)
+		}
+		os.Exit(code)
+	}
+
+	if *resume != "" {
+		if err := sess.restore(*resume); err != nil {
+			errorf("%s", err)
+			os.Exit(2)
+		}
+	}
+	slv := sess.slv
+	if !*quiet && !*noBanner && !*plain {
+		printBanner(slv, sess.targets, reachLow, reachHigh)
+	}
+	if *resume != "" && !*quiet {
+		printf("Resumed a session of %d hand(s) from %s.\n", len(sess.hands), *resume)
+	}
+
+	lines := newLineReader()
+	defer lines.close()
+	if *keepHistory {
+		if err := sess.loadHistory(lines); err != nil {
+			errorf("cannot load the history: %s", err)
+		}
+	}
 	for {
-		fmt.Print("\nEnter 4 numbers (or 'quit' to exit): ")
-		if !scanner.Scan() {
+		prompt := ""
+		if !*quiet {
+			prompt = plainText(sprintf("\nEnter %s (or 'quit' to exit): ", describeCount()))
+		}
+		input, ok := lines.readLine(prompt)
+		if !ok {
 			break
 		}
-		input := scanner.Text()
 		if strings.ToLower(strings.TrimSpace(input)) == "quit" {
-			fmt.Println("Thank you for playing!")
+			if !*quiet {
+				printf("Thank you for playing!\n")
+			}
 			break
 		}
-		nums, err := parseInput(input)
-		if err != nil {
-			fmt.Printf("Error: %s\n", err)
+		if strings.HasPrefix(strings.TrimSpace(input), ":") {
+			sess.runMetaCommand(input)
 			continue
 		}
-		fmt.Printf("\nSearching for solutions with: %.0f, %.0f, %.0f, %.0f\n", nums[0], nums[1], nums[2], nums[3])
-		fmt.Println("===============================")
-
-		var uniqueSolutions []Expression
-		seenKeys := make(map[string]bool)
-		permutations := generatePermutations(nums)
-		operationCombos := generateOperations()
-
-		for _, perm := range permutations {
-			for _, ops := range operationCombos {
-				solutions := findSolutions(perm, ops, seenKeys)
-				uniqueSolutions = append(uniqueSolutions, solutions...)
-			}
+		nums, err := sess.slv.ParseHandN(input, *count)
+		if err != nil {
+			errorf("%s", err)
+			continue
 		}
-		if len(uniqueSolutions) == 0 {
-			fmt.Println("No solutions found for these numbers.")
-		} else {
-			fmt.Printf("Found %d unique solution(s):\n\n", len(uniqueSolutions))
-			for i, solution := range uniqueSolutions {
-				fmt.Printf("%d. %s = %.0f\n", i+1, solution.formula, solution.value)
-			}
+		lines.remember(input)
+		sess.solveInteractive(nums)
+		if isInterrupted() {
+			break
 		}
-		fmt.Println("\n===============================")
+	}
+	if isInterrupted() && !*quiet {
+		blankLine()
+		sess.printStats()
+	}
+	if err := saveCache(cache); err != nil {
+		errorf("cannot save cache: %s", err)
+		os.Exit(1)
+	}
+	if isInterrupted() {
+		os.Exit(130)
 	}
 }
 
+// loadHistory puts the hands of the -keep-history file before those of
+// the session and adds them to the history of lines, so :history and the
+// up arrow bring them back, and makes the session add the hands it solves
+// to the file.
+func (sess *session) loadHistory(lines lineReader) error {
+	path := defaultHistoryFile()
+	if path == "" {
+		return errors.New("there is no config directory to keep it in")
+	}
+	hands, err := loadHistory(path)
+	if err != nil {
+		return err
+	}
+	for _, nums := range hands {
+		lines.remember(formatNumbers(nums))
+	}
+	sess.hands = append(hands, sess.hands...)
+	sess.historyFile = path
+	return nil
+}
 
+// printBanner prints the welcome banner and rules of the interactive loop.
+func printBanner(slv *solver.Solver, targets []float64, reachLow, reachHigh int) {
+	printf("WELCOME TO THE 24 GAME SOLVER\n")
+	fmt.Println(separatorLine)
+	printf("Rules:\n")
+	printf("- Enter %s (%s) or cards (A, 2-9, T, J, Q, K)\n", describeCount(), describeNumbers())
+	printf("- Format: 1 2 3 4 or 1,2,3,4 or 1234 or A T J K\n")
+	if *reachable != "" {
+		printf("- The program will list every whole number from %d to %d the numbers can make.\n", reachLow, reachHigh)
+	} else {
+		printf("- The program will find all unique ways to make %s.\n", describeTargets(slv, targets))
+	}
+	supports := slv.Operators()
+	if *sqrtDepth > 0 {
+		supports = append(supports, "sqrt")
+	}
+	if *factorials > 0 {
+		supports = append(supports, "!")
+	}
+	if *negation {
+		supports = append(supports, sprintf("negation"))
+	}
+	if *concat {
+		supports = append(supports, sprintf("concatenation"))
+	}
+	if *subsets {
+		supports = append(supports, sprintf("subsets"))
+	}
+	if *reuse > 1 {
+		supports = append(supports, sprintf("reuse up to %d times", *reuse))
+	}
+	printf("- Supports: %s\n", strings.Join(supports, ", "))
+	printf("- Type :help for the commands that change these settings\n")
+	fmt.Println(separatorLine)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/x0root/24Solver/expr"
+	"github.com/x0root/24Solver/solver"
+)
+
+// printMarkdown prints solutions as a Markdown numbered list, each formula
+// in a code span so its operators are kept as they are, e.g.
+// "1. `8 / (3 - 8 / 3)` = 24", with its variants nested under it.
+func printMarkdown(solutions []solver.Solution, nums []float64) {
+	for i, solution := range solutions {
+		fmt.Printf("%d. `%s` = %s%s\n", i+1, displayFormula(solution.Formula), formatNumber(solution.Value), numbersUsed(solution, nums))
+		if len(solution.Variants) > 1 {
+			for _, variant := range solution.Variants[1:] {
+				fmt.Printf("   - same as `%s`\n", displayFormula(variant))
+			}
+		}
+	}
+}
+
+// printLaTeX prints solutions as a LaTeX enumerate list, each as an
+// equation, e.g. "\item $\frac{8}{3 - \frac{8}{3}} = 24$", followed by its
+// variants.
+func printLaTeX(solutions []solver.Solution, nums []float64) {
+	fmt.Println(`\begin{enumerate}`)
+	for _, solution := range solutions {
+		fmt.Printf("\\item $%s = %s$%s\n", solution.Tree.LaTeX(), formatNumber(solution.Value), numbersUsed(solution, nums))
+		if len(solution.Variants) > 1 {
+			for _, variant := range solution.Variants[1:] {
+				fmt.Printf("  \\\\ same as $%s$\n", latexFormula(variant))
+			}
+		}
+	}
+	fmt.Println(`\end{enumerate}`)
+}
+
+// latexFormula renders a formula of the solver in LaTeX.
+func latexFormula(formula string) string {
+	tree, err := expr.Parse(formula)
+	if err != nil {
+		return `\text{` + latexEscape(formula) + `}`
+	}
+	return tree.LaTeX()
+}
+
+// latexEscape escapes the characters of text that LaTeX treats specially.
+var latexEscape = strings.NewReplacer(
+	`\`, `\textbackslash{}`, "&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`,
+	"_", `\_`, "{", `\{`, "}", `\}`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+).Replace
+
+// markdownWriter returns the functions resultWriter uses for -format
+// markdown: a table with a row per puzzle.
+func markdownWriter(w *bufio.Writer, sess *session) (write func(puzzleResult), finish func()) {
+	fmt.Fprintln(w, translate("| Numbers | Target | Solutions | First solution |"))
+	fmt.Fprintln(w, "| --- | ---: | ---: | --- |")
+	cell := strings.NewReplacer("|", `\|`).Replace
+	write = func(result puzzleResult) {
+		numbers, target, count, first := resultCells(sess, result)
+		if first != "" && result.Error == "" {
+			first = "`" + displayFormula(first) + "`"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", cell(numbers), target, count, cell(first))
+		w.Flush()
+	}
+	return write, func() {}
+}
+
+// latexWriter returns the functions resultWriter uses for -format latex: a
+// tabular environment with a row per puzzle.
+func latexWriter(w *bufio.Writer, sess *session) (write func(puzzleResult), finish func()) {
+	fmt.Fprintln(w, `\begin{tabular}{lrrl}`)
+	fmt.Fprintln(w, translate(`Numbers & Target & Solutions & First solution \\`))
+	fmt.Fprintln(w, `\hline`)
+	write = func(result puzzleResult) {
+		numbers, target, count, first := resultCells(sess, result)
+		if result.Error != "" {
+			first = latexEscape(first)
+		} else if first != "" {
+			first = "$" + latexFormula(first) + "$"
+		}
+		fmt.Fprintf(w, "%s & %s & %s & %s \\\\\n", latexEscape(numbers), target, count, first)
+		w.Flush()
+	}
+	return write, func() { fmt.Fprintln(w, `\end{tabular}`) }
+}
+
+// resultCells returns the cells of the table row of one result: its
+// numbers, target, how many solutions it has and the first of them in the
+// order of -sort, or for a puzzle that could not be solved as given its
+// input and the error, with the target and count empty.
+func resultCells(sess *session, result puzzleResult) (numbers, target, count, first string) {
+	if result.Error != "" {
+		numbers = result.Input
+		if numbers == "" {
+			numbers = formatHand(result.Nums)
+		}
+		return numbers, "", "", "error: " + result.Error
+	}
+	if len(result.Solutions) > 0 {
+		solver.Sort(result.Solutions, sess.order)
+		first = result.Solutions[0].Formula
+	}
+	return formatHand(result.Nums), formatNumber(result.Target), strconv.Itoa(result.Count), first
+}
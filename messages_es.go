@@ -0,0 +1,201 @@
+package main
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// messagesES are the Spanish translations of the messages, keyed by their
+// English text.
+var messagesES = map[string]string{
+	// Solutions
+	"Search truncated after %s; the solutions below may be incomplete.\n\n": "Búsqueda interrumpida tras %s; puede que falten soluciones.\n\n",
+	"No solutions found for these numbers.\n":                               "No se encontraron soluciones para estos números.\n",
+	"   Step %d: %s\n": "   Paso %d: %s\n",
+	"Showing the first %d unique solution(s) found:\n\n":             "Primeras %d solución(es) única(s) encontradas:\n\n",
+	"Found %d unique solution(s), %d variant(s):\n\n":                "Se encontraron %d solución(es) única(s), %d variante(s):\n\n",
+	"Found %d solution(s) as written, %d of them unique:\n\n":        "Se encontraron %d solución(es) tal como se escriben, %d de ellas única(s):\n\n",
+	"One of %d unique solution(s), picked at random:\n\n":            "Una de %d solución(es) única(s), elegida al azar:\n\n",
+	"Found %d unique solution(s):\n\n":                               "Se encontraron %d solución(es) única(s):\n\n",
+	"     same as %s\n":                                              "     igual que %s\n",
+	"     key: %s\n":                                                 "     clave: %s\n",
+	"     from: %s with %s\n":                                        "     de: %s con %s\n",
+	"     collapsed: none\n":                                         "     agrupadas: ninguna\n",
+	"     collapsed: %d duplicate(s)\n":                              "     agrupadas: %d duplicada(s)\n",
+	"Wrote %d diagram(s) to %s\n":                                    "Escrito(s) %d diagrama(s) en %s\n",
+	"Target %s: ":                                                    "Objetivo %s: ",
+	"no solutions found.\n":                                          "no se encontraron soluciones.\n",
+	"The closest you can get is %s away:\n\n":                        "Lo más cerca que se puede llegar es a %s:\n\n",
+	"Search interrupted; the solutions below may be incomplete.\n\n": "Búsqueda interrumpida; puede que falten soluciones.\n\n",
+	"Stats: answered from the cache in %s\n":                         "Estadísticas: respondido desde la caché en %s\n",
+	"Stats: %d evaluated, %d pruned, %d division(s) by zero skipped, %d duplicate(s) collapsed, %s\n": "Estadísticas: %d evaluadas, %d podadas, %d división(es) por cero omitida(s), %d duplicada(s) agrupada(s), %s\n",
+
+	// Subcommands
+	"No hands make %s with %s.\n":                "Ninguna mano da %s con %s.\n",
+	"Found %d hand(s) that make %s with %s:\n\n": "Se encontraron %d mano(s) que dan %s con %s:\n\n",
+	"Incorrect: %s\n":                            "Incorrecto: %s\n",
+	"Correct! %s makes %s\n":                     "¡Correcto! %s da %s\n",
+	"Correct! %s = %s\n":                         "¡Correcto! %s = %s\n",
+	"invalid hand: %s":                           "mano no válida: %s",
+	"give the answer and the hand, e.g. verify \"8/(3-8/3)\" 3 3 8 8":            "indique la respuesta y la mano, p. ej. verify \"8/(3-8/3)\" 3 3 8 8",
+	"Make %s from each hand. Type 'skip' to see a solution or 'quit' to stop.\n": "Consiga %s con cada mano. Escriba 'skip' para ver una solución o 'quit' para terminar.\n",
+	"\nHand %d (%s): %s\n": "\nMano %d (%s): %s\n",
+	"Your answer: ":        "Su respuesta: ",
+	"One solution is %s\n": "Una solución es %s\n",
+	"Not quite: %s\n":      "No exactamente: %s\n",
+	"Correct!\n":           "¡Correcto!\n",
+	"give the file to save the quiz to, e.g. :save quiz.json":                 "indique el archivo donde guardar el juego, p. ej. :save quiz.json",
+	"Saved the quiz to %s.\n":                                                 "Juego guardado en %s.\n",
+	"%s holds no quiz; continue it without the quiz command":                  "%s no contiene ningún juego; continúelo sin el comando quiz",
+	"\nInterrupted after %s: %d of %d puzzle(s) done, %d of them solvable.\n": "\nInterrumpido tras %s: %d de %d problema(s) hechos, %d de ellos con solución.\n",
+	"\n\nInterrupted after %s.":                                               "\n\nInterrumpido tras %s.",
+	"\nYou solved %d of %d hand(s).\n":                                        "\nResolvió %d de %d mano(s).\n",
+	"Solved %d of %d puzzle(s); results are in %s\n":                          "Resuelto(s) %d de %d problema(s); los resultados están en %s\n",
+	"%s: error: %s\n":                                           "%s: error: %s\n",
+	" (search truncated)":                                       " (búsqueda interrumpida)",
+	"%s: no solutions%s\n":                                      "%s: sin soluciones%s\n",
+	"%s: %d solution(s)%s, first %s\n":                          "%s: %d solución(es)%s, la primera %s\n",
+	"Wrote a worksheet of %d puzzle(s) to %s\n":                 "Escrita una hoja de %d problema(s) en %s\n",
+	"Solved %d hand(s) in %s: %d solvable, %d solution(s)\n":    "Resuelta(s) %d mano(s) en %s: %d con solución, %d solución(es)\n",
+	"%.0f hands/s, %s/hand\n":                                   "%.0f manos/s, %s/mano\n",
+	"%.0f allocs/hand, %.1f KB/hand\n":                          "%.0f asignaciones/mano, %.1f KB/mano\n",
+	"Serving solutions at http://localhost%s/solve\n":           "Sirviendo soluciones en http://localhost%s/solve\n",
+	"Targets %d to %d with %s:\n\n":                             "Objetivos de %d a %d con %s:\n\n",
+	"\nReached %d of %d target(s).\n":                           "\nAlcanzado(s) %d de %d objetivo(s).\n",
+	"Not reached: %s\n":                                         "Sin alcanzar: %s\n",
+	"Cards: %s -> %s\n":                                         "Cartas: %s -> %s\n",
+	"No solutions found for these cards.\n":                     "No se encontraron soluciones para estas cartas.\n",
+	"Numbers: %s\n":                                             "Números: %s\n",
+	"Target: %d\n":                                              "Objetivo: %d\n",
+	"Found %d solution(s):\n\n":                                 "Se encontraron %d solución(es):\n\n",
+	"No value can be made from these numbers.\n":                "No se puede obtener ningún valor con estos números.\n",
+	"The target cannot be reached; the closest is %s away:\n\n": "No se puede alcanzar el objetivo; lo más cerca está a %s:\n\n",
+	"... and %d more\n":                                         "... y %d más\n",
+	"%d. %s = %s (%d number(s))\n":                              "%d. %s = %s (%d número(s))\n",
+	"hand %d of %d, ":                                           "mano %d de %d, ",
+	"\r\u001b[KSearching: %s%d of %d steps (%d%%), %s":          "\r\u001b[KBuscando: %s%d de %d pasos (%d%%), %s",
+	"ROOT\tCOUNT\tSOLUTION":                                     "RAÍZ\tCANTIDAD\tSOLUCIÓN",
+	"number":                                                    "número",
+	"| Numbers | Target | Solutions | First solution |":         "| Números | Objetivo | Soluciones | Primera solución |",
+	"Numbers & Target & Solutions & First solution \\\\":        "Números & Objetivo & Soluciones & Primera solución \\\\",
+
+	// Interactive solver
+	"Thank you for playing!\n":                          "¡Gracias por jugar!\n",
+	"WELCOME TO THE 24 GAME SOLVER\n":                   "BIENVENIDO AL SOLUCIONADOR DEL JUEGO DEL 24\n",
+	"Rules:\n":                                          "Reglas:\n",
+	"- Enter %s (%s) or cards (A, 2-9, T, J, Q, K)\n":   "- Introduzca %s (%s) o cartas (A, 2-9, T, J, Q, K)\n",
+	"- Format: 1 2 3 4 or 1,2,3,4 or 1234 or A T J K\n": "- Formato: 1 2 3 4 o 1,2,3,4 o 1234 o A T J K\n",
+	"- The program will list every whole number from %d to %d the numbers can make.\n": "- El programa listará cada número entero de %d a %d que se pueda obtener con los números.\n",
+	"- The program will find all unique ways to make %s.\n":                            "- El programa encontrará todas las formas únicas de obtener %s.\n",
+	"- Supports: %s\n": "- Admite: %s\n",
+	"- Type :help for the commands that change these settings\n": "- Escriba :help para ver los comandos que cambian estos ajustes\n",
+	"\nSearching for solutions with: %s\n":                       "\nBuscando soluciones con: %s\n",
+	"\nEnter %s (or 'quit' to exit): ":                           "\nIntroduzca %s (o 'quit' para salir): ",
+	" (%d numbers)":                                              " (%d números)",
+	" (%d of %d numbers)":                                        " (%d de %d números)",
+	"%d to %d numbers":                                           "de %d a %d números",
+	"%d numbers":                                                 "%d números",
+	"%s and %s":                                                  "%s y %s",
+	"a value from %s to %s":                                      "un valor de %s a %s",
+	"any whole number":                                           "cualquier número entero",
+	"any whole number from 1":                                    "cualquier número entero desde 1",
+	"-13 to 13":                                                  "de -13 a 13",
+	"negation":                                                   "negación",
+	"concatenation":                                              "concatenación",
+	"subsets":                                                    "subconjuntos",
+	"reuse up to %d times":                                       "reutilizar hasta %d veces",
+	"Solving for %s from now on.\n":                              "A partir de ahora se busca %s.\n",
+	"Using %s from now on.\n":                                    "A partir de ahora se usa %s.\n",
+	"Solved %d hand(s), %d of them with solutions and %d without, in %s.\n":                              "Resueltas %d mano(s), %d con soluciones y %d sin ellas, en %s.\n",
+	"save the hands, settings and stats of this session to FILE, to continue it later with -resume FILE": "guardar las manos, los ajustes y las estadísticas de esta sesión en FILE, para continuarla más tarde con -resume FILE",
+	"give the file to save the session to, e.g. :save session.json":                                      "indique el archivo donde guardar la sesión, p. ej. :save session.json",
+	"Saved the session to %s.\n":                                                          "Sesión guardada en %s.\n",
+	"Resumed a session of %d hand(s) from %s.\n":                                          "Reanudada una sesión de %d mano(s) desde %s.\n",
+	"list the hands solved so far, numbered for :replay":                                  "listar las manos resueltas hasta ahora, numeradas para :replay",
+	"solve hand N of :history again, e.g. :replay 3":                                      "resolver de nuevo la mano N de :history, p. ej. :replay 3",
+	"give the number of a hand of :history, from 1 to %d, e.g. :replay 1":                 "indique el número de una mano de :history, de 1 a %d, p. ej. :replay 1",
+	"cannot save the history: %s":                                                         "no se puede guardar el historial: %s",
+	"cannot load the history: %s":                                                         "no se puede cargar el historial: %s",
+	"no hand has been solved yet":                                                         "todavía no se ha resuelto ninguna mano",
+	"unknown command '%s'; type :help for the list of commands":                           "comando desconocido '%s'; escriba :help para ver la lista de comandos",
+	"solve for N from now on, or several targets separated by commas, e.g. :target 24,36": "buscar N a partir de ahora, o varios objetivos separados por comas, p. ej. :target 24,36",
+	"use only the operators in OPS from now on, e.g. :ops +-*":                            "usar solo los operadores de OPS a partir de ahora, p. ej. :ops +-*",
+	"solve the previous hand again, e.g. after changing a setting":                        "resolver de nuevo la mano anterior, p. ej. tras cambiar un ajuste",
+	"show how many hands have been solved so far":                                         "mostrar cuántas manos se han resuelto hasta ahora",
+	"show this list": "mostrar esta lista",
+
+	// Errors
+	"unknown command '%s'; run with -h for the list of commands": "comando desconocido '%s'; ejecute con -h para ver la lista de comandos",
+	"Error:":      "Error:",
+	"Error: %s\n": "Error: %s\n",
+	"Warning: ignoring %s, which is not a setting; the variables are named like the flags, e.g. %sTARGET\n": "Aviso: se ignora %s, que no es un ajuste; las variables se llaman como las opciones, p. ej. %sTARGET\n",
+	"give the hand to solve, e.g. solve 3 3 8 8":                                                            "indique la mano que resolver, p. ej. solve 3 3 8 8",
+	"give the shell to complete for: completion bash, zsh or fish":                                          "indique el intérprete para el que completar: completion bash, zsh o fish",
+	"-count must be 0 or from %d to %d":                                                                     "-count debe ser 0 o de %d a %d",
+	"unknown format '%s', want one of %s":                                                                   "formato desconocido '%s'; use uno de %s",
+	"several targets cannot be combined with -tolerance or -target-range":                                   "no se pueden combinar varios objetivos con -tolerance ni -target-range",
+	"-forbid-op %q leaves no operators to combine the numbers with":                                         "-forbid-op %q no deja operadores con los que combinar los números",
+	"cannot load cache: %s":                                                                                 "no se puede cargar la caché: %s",
+	"cannot save cache: %s":                                                                                 "no se puede guardar la caché: %s",
+	"-n must be at least 1, not %d":                                                                         "-n debe ser al menos 1, no %d",
+	"-columns must be from 1 to 8, not %d":                                                                  "-columns debe ser de 1 a 8, no %d",
+	"-copies must be from 1 to 6, not %d":                                                                   "-copies debe ser de 1 a 6, no %d",
+	"-from %d is above -to %d":                                                                              "-from %d es mayor que -to %d",
+	"-large must be from 0 to %d, not %d":                                                                   "-large debe ser de 0 a %d, no %d",
+	"the target must be from 100 to 999, not %d":                                                            "el objetivo debe ser de 100 a 999, no %d",
+	"only %d different hands found for %d puzzles":                                                          "solo se encontraron %d manos distintas para %d problemas",
+	"cannot write a PDF: wkhtmltopdf was not found; install it, or write the worksheet as .html and print it from a browser": "no se puede escribir un PDF: no se encontró wkhtmltopdf; instálelo, o escriba la hoja como .html e imprímala desde un navegador",
+	"%s is not a Countdown number, or appears too often: use 1-10 at most twice and 25, 50, 75, 100 once":                    "%s no es un número de Cifras, o aparece demasiadas veces: use 1-10 como mucho dos veces y 25, 50, 75, 100 una vez",
+	"write the hand as five cards, -> and the target card, e.g. 2 4 6 14 20 -> 17":                                           "escriba la mano como cinco cartas, -> y la carta objetivo, p. ej. 2 4 6 14 20 -> 17",
+	"invalid target card '%s'":                          "carta objetivo no válida '%s'",
+	"Krypto cards go from 1 to 25, not %s":              "las cartas de Krypto van de 1 a 25, no %s",
+	"no numbers given":                                  "no se indicaron números",
+	"cannot read %s: -input takes a .csv or .json file": "no se puede leer %s: -input admite un archivo .csv o .json",
+	"no solvable hand found in %d deals":                "no se encontró ninguna mano con solución en %d repartos",
+	"no %s hand found in %d deals":                      "no se encontró ninguna mano %s en %d repartos",
+	"invalid target %q, want a number or several separated by commas such as 24,36,100":        "objetivo no válido %q; indique un número o varios separados por comas, como 24,36,100",
+	"invalid range %q, want LOW..HIGH such as 20..30":                                          "rango no válido %q; indique MÍNIMO..MÁXIMO, como 20..30",
+	"invalid range %q, want whole numbers such as 1..100":                                      "rango no válido %q; indique números enteros, como 1..100",
+	"there is no config directory to keep it in":                                               "no hay ningún directorio de configuración donde guardarlo",
+	"%s holds a quiz; continue it with -resume %s quiz":                                        "%s contiene un juego; continúelo con -resume %s quiz",
+	"%s: invalid elapsed time: %v":                                                             "%s: tiempo transcurrido no válido: %v",
+	"invalid %s %q: %v":                                                                        "%s no válido %q: %v",
+	"%s: unknown setting %q; settings are named like the flags, e.g. target":                   "%s: ajuste desconocido %q; los ajustes se llaman como las opciones, p. ej. target",
+	"%s: invalid %s: %v":                                                                       "%s: %s no válido: %v",
+	"%s: invalid %s %q: %v":                                                                    "%s: %s no válido %q: %v",
+	"want a string, number, boolean or list, not %T":                                           "se esperaba una cadena, un número, un booleano o una lista, no %T",
+	"cannot render the diagrams: dot was not found; install Graphviz or leave out -dot-render": "no se pueden dibujar los diagramas: no se encontró dot; instale Graphviz o quite -dot-render",
+
+	// Errors of the solver
+	"you must enter exactly 4 numbers":                         "debe introducir exactamente 4 números",
+	"no operators left to combine the numbers with":            "no quedan operadores con los que combinar los números",
+	"you must enter exactly %d numbers, found %d":              "debe introducir exactamente %d números, se encontraron %d",
+	"hands must have from %d to %d numbers, found %d":          "las manos deben tener de %d a %d números, se encontraron %d",
+	"'%s' is not a valid number":                               "'%s' no es un número válido",
+	"numbers must be greater than 0, found: %g":                "los números deben ser mayores que 0, se encontró: %g",
+	"numbers must be greater than 0 and at most %g, found: %g": "los números deben ser mayores que 0 y como mucho %g, se encontró: %g",
+	"numbers must be finite, found: %g":                        "los números deben ser finitos, se encontró: %g",
+	"numbers must be from %g to %g, found: %g":                 "los números deben ser de %g a %g, se encontró: %g",
+	"numbers must be whole numbers, found: %g":                 "los números deben ser enteros, se encontró: %g",
+	"numbers must be whole numbers from %g up, found: %g":      "los números deben ser enteros desde %g, se encontró: %g",
+	"numbers must be whole numbers from %g to %g, found: %g":   "los números deben ser enteros de %g a %g, se encontró: %g",
+	"invalid answer":   "respuesta no válida",
+	"invalid template": "plantilla no válida",
+	"answer must use each number of the hand exactly once":            "la respuesta debe usar cada número de la mano exactamente una vez",
+	"answer must only divide evenly, with no fractions along the way": "la respuesta solo puede hacer divisiones exactas, sin fracciones por el camino",
+	"answer must not go below zero along the way":                     "la respuesta no puede bajar de cero por el camino",
+	"answer reaches %g along the way, beyond the cap of %g":           "la respuesta llega a %g por el camino, por encima del límite de %g",
+	"answer evaluates to %g, not within %g of %g":                     "la respuesta vale %g, no está a menos de %g de %g",
+	"answer evaluates to %g, not %g":                                  "la respuesta vale %g, no %g",
+	"division by zero":                                                "división por cero",
+	"undefined power":                                                 "potencia no definida",
+	"remainder of a number that is not whole":                         "resto de un número que no es entero",
+	"square root of a negative number":                                "raíz cuadrada de un número negativo",
+	"factorial of a number that is not a whole number from 0 to 170":  "factorial de un número que no es un entero de 0 a 170",
+}
+
+func init() {
+	for key, msg := range messagesES {
+		message.SetString(language.Spanish, key, msg)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// separatorLine divides the hands of the interactive loop.
+const separatorLine = "==============================="
+
+// plainText returns s as -plain prints it: with the blank lines and the
+// indentation of its lines dropped, so every message is a single line a
+// screen reader reads as it is. Without -plain s is returned unchanged.
+func plainText(s string) string {
+	if !*plain {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	kept := lines[:0]
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " ")
+		// The last piece is what follows the final newline, e.g. a
+		// prompt, and is kept even when empty.
+		if line == "" && i < len(lines)-1 {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// separator prints the line that divides the hands of the interactive
+// loop, except with -plain.
+func separator() {
+	if !*plain {
+		fmt.Println(separatorLine)
+	}
+}
+
+// blankLine prints an empty line, except with -plain.
+func blankLine() {
+	if !*plain {
+		fmt.Println()
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// How long a search runs before its progress is shown, and how often the
+// line is redrawn after that.
+const (
+	progressDelay    = time.Second
+	progressInterval = 100 * time.Millisecond
+)
+
+// progressLine shows the progress of slow searches on a line of stderr
+// that each report redraws, see solver.WithProgress.
+type progressLine struct {
+	// shown is set once the line has been drawn, and drawn is the
+	// elapsed time of the search when it last was.
+	shown bool
+	drawn time.Duration
+}
+
+// searchProgress is the progress line of the searches of the interactive
+// loop and solve, which run one at a time. Batch mode and serve solve with
+// options that leave it out, since their searches run side by side.
+var searchProgress progressLine
+
+// progressOptions returns the option that shows the progress of slow
+// searches on stderr, unless -quiet is set or stderr is not a terminal.
+func progressOptions() []solver.Option {
+	if *quiet || *plain || !isTerminal(os.Stderr) {
+		return nil
+	}
+	return []solver.Option{solver.WithProgress(searchProgress.report)}
+}
+
+// report draws p, once the search has run for progressDelay and at most
+// every progressInterval.
+func (l *progressLine) report(p solver.Progress) {
+	if p.Elapsed < progressDelay || l.shown && p.Elapsed-l.drawn < progressInterval {
+		return
+	}
+	hand := ""
+	if p.Hands > 1 {
+		hand = sprintf("hand %d of %d, ", p.Hand, p.Hands)
+	}
+	fmt.Fprint(os.Stderr, sprintf("\r\x1b[KSearching: %s%d of %d steps (%d%%), %s", hand, p.Done, p.Total, p.Done*100/p.Total, p.Elapsed.Round(100*time.Millisecond)))
+	l.shown, l.drawn = true, p.Elapsed
+}
+
+// clear erases the line when it was drawn, once the search is over.
+func (l *progressLine) clear() {
+	if l.shown {
+		fmt.Fprint(os.Stderr, "\r\x1b[K")
+	}
+	*l = progressLine{}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// runQuiz handles the quiz command: it deals solvable hands as gen does
+// and checks the answers typed for each, with solver.Verify, until the
+// rounds are over or the player quits, then prints the score. "skip"
+// shows a solution and moves on to the next hand, and ":save FILE" saves
+// the progress of the quiz, which -resume FILE continues.
+func runQuiz(opts []solver.Option, args []string) int {
+	fs := flag.NewFlagSet("quiz", flag.ContinueOnError)
+	difficulty := fs.String("difficulty", "", "only deal hands that are `easy`, medium or hard (default any solvable hand)")
+	rounds := fs.Int("rounds", 5, "how many hands to deal (0 means until 'quit')")
+	seed := fs.Uint64("seed", 0, "seed for the deals, so a quiz can be replayed (0 picks one)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	progress := quizProgress{Seed: *seed, Difficulty: *difficulty, Rounds: *rounds}
+	if progress.Seed == 0 {
+		// The seed is picked here rather than by newRand so that a saved
+		// quiz deals the same hands when it is resumed.
+		progress.Seed = rand.Uint64() | 1
+	}
+	if *resume != "" {
+		saved, err := readSession(*resume)
+		if err != nil {
+			errorf("%s", err)
+			return 2
+		}
+		if saved.Quiz == nil {
+			errorf("%s holds no quiz; continue it without the quiz command", *resume)
+			return 2
+		}
+		progress = *saved.Quiz
+	}
+	want, err := parseWantedDifficulty(progress.Difficulty)
+	if err != nil {
+		errorf("%s", err)
+		return 2
+	}
+
+	slv := solver.New(opts...)
+	rng := newRand(progress.Seed)
+	// Deal the hands a resumed quiz has played again, to go on from the
+	// next one.
+	for range progress.Played {
+		if _, _, _, err := deal(slv, rng, want); err != nil {
+			errorf("%s", err)
+			return 1
+		}
+	}
+	answers := scanLines(os.Stdin)
+	start := time.Now()
+	printf("Make %s from each hand. Type 'skip' to see a solution or 'quit' to stop.\n", describeTarget(slv))
+quiz:
+	for progress.Rounds == 0 || progress.Played < progress.Rounds {
+		nums, solutions, rating, err := deal(slv, rng, want)
+		if err != nil {
+			errorf("%s", err)
+			return 1
+		}
+		progress.Played++
+		printf("\nHand %d (%s): %s\n", progress.Played, rating, formatHand(nums))
+		for {
+			printf("Your answer: ")
+			answer, ok := nextLine(answers)
+			if !ok {
+				break quiz
+			}
+			answer = strings.TrimSpace(answer)
+			switch strings.ToLower(answer) {
+			case "":
+				continue
+			case "quit":
+				break quiz
+			case "skip":
+				solver.Sort(solutions, solver.SortElegance)
+				printf("One solution is %s\n", displayFormula(solutions[0].Formula))
+				continue quiz
+			}
+			if path, ok := strings.CutPrefix(answer, ":save"); ok {
+				path = strings.TrimSpace(path)
+				if path == "" {
+					errorf("give the file to save the quiz to, e.g. :save quiz.json")
+					continue
+				}
+				// The hand being played is dealt again on resuming.
+				saved := progress
+				saved.Played--
+				if err := writeSession(path, savedSession{Quiz: &saved}); err != nil {
+					errorf("%s", err)
+					continue
+				}
+				printf("Saved the quiz to %s.\n", path)
+				continue
+			}
+			if err := slv.Verify(answer, nums); err != nil {
+				printf("Not quite: %s\n", err)
+				continue
+			}
+			progress.Solved++
+			printf("Correct!\n")
+			continue quiz
+		}
+	}
+	if isInterrupted() {
+		printf("\n\nInterrupted after %s.", time.Since(start).Round(time.Second))
+	}
+	printf("\nYou solved %d of %d hand(s).\n", progress.Solved, progress.Played)
+	if isInterrupted() {
+		return 130
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	texttemplate "text/template"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// templateFuncs are the functions -output-template files can call besides
+// those of text/template.
+var templateFuncs = texttemplate.FuncMap{
+	"hand":    formatHand,
+	"number":  formatNumber,
+	"display": displayFormula,
+}
+
+// puzzleData is what an -output-template "puzzle" template is run on, once
+// per hand.
+type puzzleData struct {
+	Numbers   []float64
+	Target    float64
+	Count     int
+	Solutions []solutionData
+	// Truncated is set when -timeout ended the search early.
+	Truncated bool
+	// Input and Error are set in batch mode for a puzzle that could not be
+	// solved as given, with Input the line or row it was read from.
+	Input string
+	Error string
+}
+
+// solutionData is what an -output-template is run on, once per solution.
+type solutionData struct {
+	solver.Solution
+	// Index counts the solutions of the hand from 1.
+	Index int
+	// Numbers are the numbers the solution uses, in the order they
+	// appear in its formula.
+	Numbers []float64
+	Target  float64
+}
+
+// loadOutputTemplate parses the -output-template file at path.
+func loadOutputTemplate(path string) (*texttemplate.Template, error) {
+	return texttemplate.New(filepath.Base(path)).Funcs(templateFuncs).ParseFiles(path)
+}
+
+// newPuzzleData returns the data of the templates for the solutions of
+// nums for target.
+func newPuzzleData(nums []float64, target float64, solutions []solver.Solution, truncated bool) puzzleData {
+	data := puzzleData{Numbers: nums, Target: target, Count: len(solutions), Truncated: truncated}
+	for i, solution := range solutions {
+		data.Solutions = append(data.Solutions, solutionData{Solution: solution, Index: i + 1, Numbers: solution.Tree.Leaves(), Target: target})
+	}
+	return data
+}
+
+// executeTemplate writes the output of tmpl for one hand to w: its
+// "puzzle" template when it defines one, which may range over .Solutions
+// itself, or else tmpl itself once per solution.
+func executeTemplate(w io.Writer, tmpl *texttemplate.Template, data puzzleData) error {
+	if puzzle := tmpl.Lookup("puzzle"); puzzle != nil {
+		return puzzle.Execute(w, data)
+	}
+	for _, solution := range data.Solutions {
+		if err := tmpl.Execute(w, solution); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printTemplate prints the solutions of nums for target with the
+// -output-template of the session, in place of every other line the
+// interactive loop and solve print for a hand.
+func (sess *session) printTemplate(nums []float64, target float64, solutions []solver.Solution, truncated bool) {
+	if err := executeTemplate(os.Stdout, sess.tmpl, newPuzzleData(nums, target, solutions, truncated)); err != nil {
+		errorf("%s", err)
+	}
+}
+
+// templateWriter returns the functions resultWriter uses with
+// -output-template. A puzzle that could not be solved as given gets the
+// line of the text format unless the template defines "puzzle".
+func templateWriter(w *bufio.Writer, sess *session) (write func(puzzleResult), finish func()) {
+	write = func(result puzzleResult) {
+		if result.Error != "" && sess.tmpl.Lookup("puzzle") == nil {
+			writeResultLine(w, sess, result)
+		} else {
+			solver.Sort(result.Solutions, sess.order)
+			data := newPuzzleData(result.Nums, result.Target, result.Solutions, result.Truncated)
+			data.Count, data.Input, data.Error = result.Count, result.Input, result.Error
+			if err := executeTemplate(w, sess.tmpl, data); err != nil {
+				fmt.Fprint(w, sprintf("Error: %s\n", errorMessage(err)))
+			}
+		}
+		w.Flush()
+	}
+	return write, func() {}
+}
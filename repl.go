@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// replStats counts what the interactive loop has solved, for :stats.
+type replStats struct {
+	hands, solved int
+	elapsed       time.Duration
+}
+
+// metaCommands lists the commands of the interactive loop, each with what
+// it does, for :help.
+var metaCommands = [][2]string{
+	{":target N", "solve for N from now on, or several targets separated by commas, e.g. :target 24,36"},
+	{":ops OPS", "use only the operators in OPS from now on, e.g. :ops +-*"},
+	{":last", "solve the previous hand again, e.g. after changing a setting"},
+	{":history", "list the hands solved so far, numbered for :replay"},
+	{":replay N", "solve hand N of :history again, e.g. :replay 3"},
+	{":stats", "show how many hands have been solved so far"},
+	{":save FILE", "save the hands, settings and stats of this session to FILE, to continue it later with -resume FILE"},
+	{":help", "show this list"},
+}
+
+// solveInteractive solves nums as the interactive loop does, between
+// separators unless -quiet is set, and counts it for :stats.
+func (sess *session) solveInteractive(nums []float64) {
+	sess.last = nums
+	sess.hands = append(sess.hands, nums)
+	if sess.historyFile != "" {
+		if err := appendHistory(sess.historyFile, nums); err != nil {
+			errorf("cannot save the history: %s", err)
+		}
+	}
+	start := time.Now()
+	if *quiet {
+		sess.count(sess.solveHand(nums), start)
+		return
+	}
+	printf("\nSearching for solutions with: %s\n", formatHand(nums))
+	separator()
+	sess.count(sess.solveHand(nums), start)
+	blankLine()
+	separator()
+}
+
+// count records for :stats a hand whose search began at start.
+func (sess *session) count(found bool, start time.Time) {
+	sess.stats.hands++
+	if found {
+		sess.stats.solved++
+	}
+	sess.stats.elapsed += time.Since(start)
+}
+
+// runMetaCommand runs a line of the interactive loop that starts with a
+// colon, see metaCommands.
+func (sess *session) runMetaCommand(line string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case ":target":
+		if err := sess.setTarget(arg); err != nil {
+			errorf("%s", err)
+			return
+		}
+		printf("Solving for %s from now on.\n", describeTargets(sess.slv, sess.targets))
+	case ":ops":
+		if err := sess.setOps(arg); err != nil {
+			errorf("%s", err)
+			return
+		}
+		printf("Using %s from now on.\n", strings.Join(sess.slv.Operators(), ", "))
+	case ":last":
+		if sess.last == nil {
+			errorf("no hand has been solved yet")
+			return
+		}
+		sess.solveInteractive(sess.last)
+	case ":history":
+		for i, nums := range sess.hands {
+			fmt.Print(plainText(fmt.Sprintf("%4d. %s\n", i+1, formatHand(nums))))
+		}
+	case ":replay":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > len(sess.hands) {
+			errorf("give the number of a hand of :history, from 1 to %d, e.g. :replay 1", len(sess.hands))
+			return
+		}
+		sess.solveInteractive(sess.hands[n-1])
+	case ":stats":
+		sess.printStats()
+	case ":save":
+		if arg == "" {
+			errorf("give the file to save the session to, e.g. :save session.json")
+			return
+		}
+		if err := sess.save(arg); err != nil {
+			errorf("%s", err)
+			return
+		}
+		printf("Saved the session to %s.\n", arg)
+	case ":help":
+		for _, command := range metaCommands {
+			if *plain {
+				fmt.Printf("%s: %s\n", command[0], translate(command[1]))
+				continue
+			}
+			fmt.Printf("  %-10s %s\n", command[0], translate(command[1]))
+		}
+	default:
+		errorf("unknown command '%s'; type :help for the list of commands", name)
+	}
+}
+
+// printStats prints how many hands the session has solved, for :stats and
+// when Ctrl-C ends it.
+func (sess *session) printStats() {
+	stats := sess.stats
+	printf("Solved %d hand(s), %d of them with solutions and %d without, in %s.\n",
+		stats.hands, stats.solved, stats.hands-stats.solved, stats.elapsed.Round(time.Millisecond))
+}
+
+// setTarget makes the session solve for the targets of s, e.g. "24,36",
+// as :target does.
+func (sess *session) setTarget(s string) error {
+	targets, err := parseTargets(s)
+	if err != nil {
+		return err
+	}
+	// A new target replaces -tolerance and -target-range as well.
+	sess.configure(solver.WithTarget(targets[0]), solver.WithTolerance(0))
+	sess.targets = targets
+	sess.changed.Target = s
+	return nil
+}
+
+// setOps makes the session use only the operators of s, e.g. "+-*", as
+// :ops does.
+func (sess *session) setOps(s string) error {
+	ops, err := solver.ParseOperators(s)
+	if err != nil {
+		return err
+	}
+	sess.configure(solver.WithOperators(ops...))
+	sess.changed.Ops = s
+	return nil
+}
+
+// configure replaces the Solver of the session with one that adds opts to
+// the options it was created with.
+func (sess *session) configure(opts ...solver.Option) {
+	sess.opts = slices.Concat(sess.opts, opts)
+	sess.slv = solver.New(sess.opts...)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// runServe handles the serve command: an HTTP server answering the
+// requests of -json-request mode at /solve, either posted as JSON or as a
+// GET with the hand in the query, e.g. /solve?hand=3+3+8+8&target=24. The
+// options of the main flags apply, and the cache is shared by every
+// request. So that no request can take the server over, each search
+// stops after -request-timeout or when its client goes away, and a
+// request may only have -max-numbers numbers and the operators of the
+// main flags.
+func runServe(opts []solver.Option, args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	port := fs.Int("port", 8080, "the port to listen on")
+	requestTimeout := fs.Duration("request-timeout", 10*time.Second, "stop the search of a request after `D` and answer with the solutions found so far (0 means no limit)")
+	maxNumbers := fs.Int("max-numbers", 6, "reject requests with more than `N` numbers")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	allowed := solver.New(opts...).Operators()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/solve", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		fail := func(status int, err error) {
+			w.WriteHeader(status)
+			enc.Encode(errorResponse{Error: err.Error()})
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			w.Header().Set("Allow", "GET, POST")
+			fail(http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed, use GET or POST", r.Method))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+		req, err := readRequest(opts, r)
+		if err == nil {
+			err = checkRequest(req, *maxNumbers, allowed)
+		}
+		if err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+		ctx := r.Context()
+		if *requestTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, *requestTimeout)
+			defer cancel()
+		}
+		resp, err := answerRequest(ctx, opts, req)
+		if err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+		enc.Encode(resp)
+	})
+
+	addr := fmt.Sprintf(":%d", *port)
+	printf("Serving solutions at http://localhost%s/solve\n", addr)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	if err := server.ListenAndServe(); err != nil {
+		errorf("%s", err)
+		return 1
+	}
+	return 0
+}
+
+// maxRequestBytes is the largest JSON body serve reads.
+const maxRequestBytes = 1 << 16
+
+// checkRequest reports why serve turns req down: it has more than
+// maxNumbers numbers, or asks for an operator that is not in allowed.
+func checkRequest(req solveRequest, maxNumbers int, allowed []string) error {
+	if len(req.Nums) > maxNumbers {
+		return fmt.Errorf("hands may have at most %d numbers, found %d", maxNumbers, len(req.Nums))
+	}
+	if req.Ops == "" {
+		return nil
+	}
+	opSet, err := solver.ParseOperators(req.Ops)
+	if err != nil {
+		return err
+	}
+	for _, op := range opSet {
+		if !slices.Contains(allowed, op) {
+			return fmt.Errorf("operator '%s' is not allowed, use some of %s", op, strings.Join(allowed, " "))
+		}
+	}
+	return nil
+}
+
+// readRequest reads a solveRequest from the JSON body of a POST, or from
+// the hand, target and ops parameters of a GET, with the hand parsed as
+// the REPL parses it.
+func readRequest(opts []solver.Option, r *http.Request) (solveRequest, error) {
+	var req solveRequest
+	switch r.Method {
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, fmt.Errorf("invalid request: %v", err)
+		}
+		return req, nil
+	case http.MethodGet:
+		query := r.URL.Query()
+		nums, err := solver.New(opts...).ParseHandN(query.Get("hand"), 0)
+		if err != nil {
+			return req, err
+		}
+		req.Nums, req.Ops = nums, query.Get("ops")
+		if text := query.Get("target"); text != "" {
+			target, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return req, fmt.Errorf("invalid target '%s'", text)
+			}
+			req.Target = &target
+		}
+		return req, nil
+	}
+	return req, fmt.Errorf("method %s is not allowed, use GET or POST", r.Method)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// savedSession is what :save writes to a file and -resume reads back: the
+// hands an interactive session has solved, the settings its commands
+// changed and its stats, or the progress of a quiz.
+type savedSession struct {
+	Hands    [][]float64    `json:"hands,omitempty"`
+	Settings *savedSettings `json:"settings,omitempty"`
+	Stats    *savedStats    `json:"stats,omitempty"`
+	Quiz     *quizProgress  `json:"quiz,omitempty"`
+}
+
+// savedSettings are the arguments of the last :target and :ops of a
+// session, empty for those it did not change.
+type savedSettings struct {
+	Target string `json:"target,omitempty"`
+	Ops    string `json:"ops,omitempty"`
+}
+
+// savedStats is replStats in a saved session.
+type savedStats struct {
+	Hands   int    `json:"hands"`
+	Solved  int    `json:"solved"`
+	Elapsed string `json:"elapsed"`
+}
+
+// quizProgress is how far a quiz has got: the flags it was started with,
+// including the seed it deals from, and how many hands have been dealt and
+// solved.
+type quizProgress struct {
+	Seed       uint64 `json:"seed"`
+	Difficulty string `json:"difficulty,omitempty"`
+	Rounds     int    `json:"rounds"`
+	Played     int    `json:"played"`
+	Solved     int    `json:"solved"`
+}
+
+// save writes the hands, changed settings and stats of the session to
+// path.
+func (sess *session) save(path string) error {
+	changed := sess.changed
+	return writeSession(path, savedSession{
+		Hands:    sess.hands,
+		Settings: &changed,
+		Stats: &savedStats{
+			Hands:   sess.stats.hands,
+			Solved:  sess.stats.solved,
+			Elapsed: sess.stats.elapsed.String(),
+		},
+	})
+}
+
+// restore continues the session saved to path: it applies the settings it
+// changed and takes over its hands and stats, so :last and :stats carry on
+// where it stopped.
+func (sess *session) restore(path string) error {
+	saved, err := readSession(path)
+	if err != nil {
+		return err
+	}
+	if saved.Quiz != nil {
+		return newError("%s holds a quiz; continue it with -resume %s quiz", path, path)
+	}
+	if settings := saved.Settings; settings != nil {
+		if settings.Target != "" {
+			if err := sess.setTarget(settings.Target); err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+		}
+		if settings.Ops != "" {
+			if err := sess.setOps(settings.Ops); err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+		}
+	}
+	if stats := saved.Stats; stats != nil {
+		elapsed, err := time.ParseDuration(stats.Elapsed)
+		if err != nil {
+			return newError("%s: invalid elapsed time: %v", path, err)
+		}
+		sess.stats = replStats{hands: stats.Hands, solved: stats.Solved, elapsed: elapsed}
+	}
+	sess.hands = saved.Hands
+	if len(sess.hands) > 0 {
+		sess.last = sess.hands[len(sess.hands)-1]
+	}
+	return nil
+}
+
+// writeSession writes saved to path as indented JSON.
+func writeSession(path string, saved savedSession) error {
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// readSession reads a session written by writeSession from path.
+func readSession(path string) (savedSession, error) {
+	var saved savedSession
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return saved, err
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return saved, fmt.Errorf("%s: %v", path, err)
+	}
+	return saved, nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import "strings"
+
+// runSolve handles the solve command, and a hand given as arguments with
+// no command: it solves the hand, e.g. "solve 3 3 8 8", prints the result
+// as the REPL would and exits, without the banner or a prompt, so scripts
+// can use it. Every flag of the REPL applies. The exit code is 0 when the
+// hand has a solution, 1 when it has none and 2 for bad input.
+func runSolve(sess *session, args []string) int {
+	if len(args) == 0 {
+		errorf("give the hand to solve, e.g. solve 3 3 8 8")
+		return 2
+	}
+	nums, err := sess.slv.ParseHandN(strings.Join(args, " "), *count)
+	if err != nil {
+		errorf("%s", err)
+		return 2
+	}
+	if !sess.solveHand(nums) {
+		return 1
+	}
+	return 0
+}
+
+// isCommandLike reports whether arg, which is neither a command nor part
+// of a hand, looks like a mistyped command rather than a bad hand: a word
+// of letters, which cards are only one at a time.
+func isCommandLike(arg string) bool {
+	return len(arg) > 1 && !strings.ContainsAny(arg, "0123456789")
+}
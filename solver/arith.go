@@ -0,0 +1,116 @@
+package solver
+
+import (
+	"math"
+	"math/big"
+)
+
+// Arithmetic is a numeric backend the search can run on, so the same
+// search works with floats, exact integers or exact rationals. Operations
+// report false when the result is undefined or cannot be represented,
+// e.g. division by zero or integer overflow; the search then skips that
+// candidate.
+type Arithmetic[T any] interface {
+	// FromFloat converts an input or target. It reports false if x has no
+	// exact representation in T.
+	FromFloat(x float64) (T, bool)
+	// Float converts a value back for display in Node and Solution.
+	Float(x T) float64
+	Add(a, b T) (T, bool)
+	Sub(a, b T) (T, bool)
+	Mul(a, b T) (T, bool)
+	Div(a, b T) (T, bool)
+	// Equal reports whether a reaches the target b.
+	Equal(a, b T) bool
+}
+
+// Float64Arithmetic is the backend WithEpsilon selects: float64 values
+// compared with a tolerance of Epsilon. The default is FracArithmetic.
+type Float64Arithmetic struct {
+	Epsilon float64
+}
+
+func (Float64Arithmetic) FromFloat(x float64) (float64, bool) { return x, true }
+func (Float64Arithmetic) Float(x float64) float64             { return x }
+func (Float64Arithmetic) Add(a, b float64) (float64, bool)    { return a + b, true }
+func (Float64Arithmetic) Sub(a, b float64) (float64, bool)    { return a - b, true }
+func (Float64Arithmetic) Mul(a, b float64) (float64, bool)    { return a * b, true }
+
+func (Float64Arithmetic) Div(a, b float64) (float64, bool) {
+	if math.Abs(b) < 1e-9 {
+		return 0, false // Avoid division by zero.
+	}
+	return a / b, true
+}
+
+func (f Float64Arithmetic) Equal(a, b float64) bool {
+	return isApproximately(a, b, f.Epsilon)
+}
+
+// Int64Arithmetic is an exact integer backend. Division is only allowed
+// when it leaves no remainder, and any operation that would overflow is
+// rejected.
+type Int64Arithmetic struct{}
+
+func (Int64Arithmetic) FromFloat(x float64) (int64, bool) {
+	if x != math.Trunc(x) || math.Abs(x) >= 1<<63 {
+		return 0, false
+	}
+	return int64(x), true
+}
+
+func (Int64Arithmetic) Float(x int64) float64 { return float64(x) }
+
+func (Int64Arithmetic) Add(a, b int64) (int64, bool) {
+	c := a + b
+	return c, (c > a) == (b > 0)
+}
+
+func (Int64Arithmetic) Sub(a, b int64) (int64, bool) {
+	c := a - b
+	return c, (c < a) == (b > 0)
+}
+
+func (Int64Arithmetic) Mul(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	return c, c/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64)
+}
+
+func (Int64Arithmetic) Div(a, b int64) (int64, bool) {
+	if b == 0 || a%b != 0 || (a == math.MinInt64 && b == -1) {
+		return 0, false
+	}
+	return a / b, true
+}
+
+func (Int64Arithmetic) Equal(a, b int64) bool { return a == b }
+
+// RatArithmetic is an exact rational backend on math/big. It never loses
+// precision, at the cost of an allocation per operation.
+type RatArithmetic struct{}
+
+func (RatArithmetic) FromFloat(x float64) (*big.Rat, bool) {
+	r := simplestRat(x)
+	return r, r != nil
+}
+
+func (RatArithmetic) Float(x *big.Rat) float64 {
+	f, _ := x.Float64()
+	return f
+}
+
+func (RatArithmetic) Add(a, b *big.Rat) (*big.Rat, bool) { return new(big.Rat).Add(a, b), true }
+func (RatArithmetic) Sub(a, b *big.Rat) (*big.Rat, bool) { return new(big.Rat).Sub(a, b), true }
+func (RatArithmetic) Mul(a, b *big.Rat) (*big.Rat, bool) { return new(big.Rat).Mul(a, b), true }
+
+func (RatArithmetic) Div(a, b *big.Rat) (*big.Rat, bool) {
+	if b.Sign() == 0 {
+		return nil, false
+	}
+	return new(big.Rat).Quo(a, b), true
+}
+
+func (RatArithmetic) Equal(a, b *big.Rat) bool { return a.Cmp(b) == 0 }
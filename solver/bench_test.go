@@ -0,0 +1,68 @@
+package solver_test
+
+import (
+	"testing"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// benchHands are hands of the Solve hot path: one hard one, one with
+// repeats, one with many solutions and one with none.
+var benchHands = []struct {
+	name string
+	hand []float64
+}{
+	{"3_3_8_8", []float64{3, 3, 8, 8}},
+	{"4_4_4_4", []float64{4, 4, 4, 4}},
+	{"1_2_3_4", []float64{1, 2, 3, 4}},
+	{"1_1_1_1", []float64{1, 1, 1, 1}},
+}
+
+func BenchmarkSolve(b *testing.B) {
+	for _, bh := range benchHands {
+		b.Run(bh.name, func(b *testing.B) {
+			slv := solver.New(solver.WithWorkers(1))
+			b.ReportAllocs()
+			for range b.N {
+				if _, err := slv.Solve(bh.hand); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSolveFloat(b *testing.B) {
+	slv := solver.New(solver.WithWorkers(1), solver.WithEpsilon(1e-9))
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := slv.Solve([]float64{3, 3, 8, 8}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSolveFive(b *testing.B) {
+	slv := solver.New(solver.WithWorkers(1))
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := slv.Solve([]float64{1, 3, 5, 7, 9}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkStandardHands solves every hand of StandardHands, as the bench
+// command does.
+func BenchmarkStandardHands(b *testing.B) {
+	slv := solver.New(solver.WithWorkers(1))
+	hands := solver.StandardHands()
+	b.ReportAllocs()
+	for range b.N {
+		for _, hand := range hands {
+			if _, err := slv.Solve(hand); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package solver
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// Cache remembers the solutions of recently solved hands, keyed by the
+// sorted numbers of the hand and the configuration of the Solver, so a hand
+// seen again, in any order, is answered without a search. With
+// WithConcatenation the order matters, and the numbers are not sorted. It
+// holds at most a fixed number of hands and evicts the least recently used
+// one first. A Cache is safe for concurrent use and may be shared by
+// differently configured Solvers.
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key       string
+	solutions []Solution
+}
+
+// NewCache returns an empty Cache holding at most size hands. A size of
+// zero or less means no limit.
+func NewCache(size int) *Cache {
+	return &Cache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Len returns the number of hands in the cache.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// get returns a copy of the solutions stored under key.
+func (c *Cache) get(key string) ([]Solution, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return slices.Clone(elem.Value.(*cacheEntry).solutions), true
+}
+
+// put stores a copy of solutions under key, evicting the least recently
+// used hands beyond the size limit.
+func (c *Cache) put(key string, solutions []Solution) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	solutions = slices.Clone(solutions)
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).solutions = solutions
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, solutions: solutions})
+	for c.size > 0 && c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cacheFile is the form a Cache is saved in, least recently used hand
+// first so loading it back restores the order.
+type cacheFile struct {
+	Entries []cacheFileEntry `json:"entries"`
+}
+
+type cacheFileEntry struct {
+	Key       string     `json:"key"`
+	Solutions []Solution `json:"solutions"`
+}
+
+// Save writes every hand in the cache to w as JSON.
+func (c *Cache) Save(w io.Writer) error {
+	c.mu.Lock()
+	var file cacheFile
+	for elem := c.order.Back(); elem != nil; elem = elem.Prev() {
+		entry := elem.Value.(*cacheEntry)
+		file.Entries = append(file.Entries, cacheFileEntry{Key: entry.key, Solutions: entry.solutions})
+	}
+	c.mu.Unlock()
+	return json.NewEncoder(w).Encode(file)
+}
+
+// Load adds the hands saved by Save from r to the cache, as the most
+// recently used ones.
+func (c *Cache) Load(r io.Reader) error {
+	var file cacheFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return fmt.Errorf("invalid cache: %v", err)
+	}
+	for _, entry := range file.Entries {
+		c.put(entry.Key, entry.Solutions)
+	}
+	return nil
+}
+
+// cacheKey returns the key of nums in a Cache, or false when the results
+// of s cannot be cached because it has filters, which are opaque functions.
+func (s *Solver) cacheKey(nums []float64) (string, bool) {
+	if len(s.filters) > 0 {
+		return "", false
+	}
+	sorted := slices.Clone(nums)
+	if !s.concatenation {
+		// Only concatenation makes the order of the hand matter.
+		slices.Sort(sorted)
+	}
+	return fmt.Sprintf("%v target=%v±%v ops=%v sqrt=%d fact=%d neg=%t concat=%t subsets=%t uses=%d require=%v intdiv=%t nonneg=%t cap=%v max=%d mirrors=%t dedup=%s variants=%t engine=%T%v",
+		sorted, s.target, s.tolerance, s.operators, s.sqrtDepth, s.factorialMax, s.negation, s.concatenation, s.subsets, max(s.maxUses, 1), s.required, s.integerDivision, s.nonNegative, s.maxIntermediate, s.maxSolutions, s.mergeMirrors, s.dedup, s.variants, s.engine, s.engine), true
+}
@@ -0,0 +1,114 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Closest returns the solutions whose value is nearest the target and how
+// far from it they are. For a solvable hand that is Solve with a distance
+// of 0; otherwise it is every expression landing at the smallest distance
+// above or below the target, e.g. 23 and 25 for a hand that cannot make 24.
+// With WithTolerance the distance is to the nearest end of the range, so
+// 1 1 1 1 is 16 from 20..30.
+// Like a target range, it supports hands of up to 6 numbers. It returns no
+// solutions when the hand makes no value at all on the backend.
+func (s *Solver) Closest(nums []float64) ([]Solution, float64, error) {
+	return s.ClosestContext(context.Background(), nums)
+}
+
+// ClosestContext is like Closest but stops early when ctx is done.
+func (s *Solver) ClosestContext(ctx context.Context, nums []float64) ([]Solution, float64, error) {
+	if err := s.validate(nums); err != nil {
+		return nil, 0, err
+	}
+	if len(nums) > maxRangeNumbers {
+		return nil, 0, fmt.Errorf("the closest value can only be found for hands of at most %d numbers", maxRangeNumbers)
+	}
+	distance, ok, err := s.engine.nearest(ctx, s, nums)
+	if !ok || err != nil {
+		return nil, 0, err
+	}
+	// Every value within the smallest distance of the range is at that
+	// distance.
+	near := *s
+	near.tolerance = s.tolerance + distance
+	solutions, err := near.SolveContext(ctx, nums)
+	return solutions, distance, err
+}
+
+func (e typedEngine[T]) nearest(ctx context.Context, s *Solver, nums []float64) (float64, bool, error) {
+	st, terms, ok := e.newState(ctx, s, nums, []float64{s.target}, &Stats{})
+	if !ok {
+		return 0, false, nil
+	}
+	best, found := math.Inf(1), false
+	for i, hand := range s.hands(nums) {
+		if i > 0 {
+			if len(hand) > maxRangeNumbers {
+				// Reuse can make hands too large to be stored.
+				continue
+			}
+			if terms, ok = e.terms(hand); !ok {
+				continue
+			}
+		}
+		distance, ok, err := st.nearest(terms)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok && distance < best {
+			best, found = distance, true
+		}
+	}
+	return best, found, nil
+}
+
+// nearest returns how far from the target, or from the range around it
+// WithTolerance sets, the closest value terms can make is, combining the
+// values of the two parts of every split of the hand, which must all be
+// stored. It reports false if no value is reachable.
+func (st *searchState[T]) nearest(terms []term[T]) (float64, bool, error) {
+	target := st.s.target
+	best, found := math.Inf(1), false
+	consider := func(t term[T]) error {
+		if d := max(math.Abs(st.ar.Float(t.value)-target)-st.s.tolerance, 0); d < best {
+			best, found = d, true
+		}
+		return nil
+	}
+	if len(terms) == 1 {
+		consider(terms[0])
+		st.eachUnary(terms[0], &st.counts, consider)
+		return valueKey(best), true, nil
+	}
+	values, err := st.fill(terms, sameNumbers(terms))
+	if err != nil {
+		return 0, false, err
+	}
+	for _, sp := range splits(1<<len(terms) - 1) {
+		if err := st.ctx.Err(); err != nil {
+			return 0, false, err
+		}
+		for _, p := range values[sp.small].terms {
+			for _, q := range values[sp.large].terms {
+				for _, op := range st.s.operators {
+					for k, pair := range [2][2]term[T]{{p, q}, {q, p}} {
+						if k == 1 && commutative(op) {
+							break
+						}
+						value, ok := st.calculate(&st.counts, pair[0].value, pair[1].value, op)
+						if !ok {
+							continue
+						}
+						t := term[T]{value: value}
+						consider(t)
+						st.eachUnary(t, &st.counts, consider)
+					}
+				}
+			}
+		}
+	}
+	return valueKey(best), found, nil
+}
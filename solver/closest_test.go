@@ -0,0 +1,46 @@
+package solver_test
+
+import (
+	"testing"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+func TestClosest(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []solver.Option
+		hand     []float64
+		distance float64
+		values   []float64
+	}{
+		{"solvable", nil, []float64{3, 3, 8, 8}, 0, []float64{24}},
+		{"target", nil, []float64{1, 1, 1, 1}, 20, []float64{4}},
+		{"range", []solver.Option{solver.WithTarget(25), solver.WithTolerance(5)}, []float64{1, 1, 1, 1}, 16, []float64{4}},
+		{"tolerance", []solver.Option{solver.WithTolerance(0.5)}, []float64{1, 1, 1, 1}, 19.5, []float64{4}},
+		{"inside range", []solver.Option{solver.WithTarget(25), solver.WithTolerance(5)}, []float64{1, 1, 1, 9}, 0, []float64{20, 27}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solutions, distance, err := solver.New(tt.opts...).Closest(tt.hand)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if distance != tt.distance {
+				t.Errorf("distance = %g, want %g", distance, tt.distance)
+			}
+			if len(solutions) == 0 {
+				t.Fatal("no solutions")
+			}
+			for _, solution := range solutions {
+				found := false
+				for _, value := range tt.values {
+					found = found || solution.Value == value
+				}
+				if !found {
+					t.Errorf("%s = %g, want a value among %v", solution.Formula, solution.Value, tt.values)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package solver
+
+import (
+	"math"
+	"slices"
+	"strconv"
+)
+
+// concatenations returns the hands nums makes when runs of adjacent single
+// digits are written as one number, nums itself first: for 1 2 3 that is
+// 1 2 3, 1 23, 12 3 and 123. Only the dealt numbers are joined, and never
+// behind a leading 0. Without WithConcatenation it is nums alone.
+func (s *Solver) concatenations(nums []float64) [][]float64 {
+	if !s.concatenation {
+		return [][]float64{nums}
+	}
+	var result [][]float64
+	var from func(hand []float64, i int)
+	from = func(hand []float64, i int) {
+		if i == len(nums) {
+			result = append(result, hand)
+			return
+		}
+		from(append(slices.Clip(hand), nums[i]), i+1)
+		if !isDigit(nums[i]) || nums[i] == 0 {
+			return
+		}
+		digits := strconv.Itoa(int(nums[i]))
+		for j := i + 1; j < len(nums) && isDigit(nums[j]); j++ {
+			digits += strconv.Itoa(int(nums[j]))
+			joined, _ := strconv.ParseFloat(digits, 64)
+			from(append(slices.Clip(hand), joined), j+1)
+		}
+	}
+	from(nil, 0)
+	return result
+}
+
+// isDigit reports whether v is a single digit from 0 to 9.
+func isDigit(v float64) bool {
+	return v >= 0 && v <= 9 && v == math.Trunc(v)
+}
@@ -0,0 +1,47 @@
+package solver
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Dedup selects which solutions count as the same one, see WithDedup.
+type Dedup string
+
+const (
+	// DedupNone reports every expression found, each order of the
+	// operands of + and * included, even ones that print the same.
+	DedupNone Dedup = "none"
+	// DedupSyntactic merges expressions that print the same, e.g.
+	// (2*3)/(1/4) and 2*(3/(1/4)), but not 1 + 2 and 2 + 1.
+	DedupSyntactic Dedup = "syntactic"
+	// DedupCanonical merges expressions with the same canonical key, see
+	// expr.CanonicalKey: those that only differ in the order and grouping
+	// of + and of *. It is the default.
+	DedupCanonical Dedup = "canonical"
+	// DedupAggressive also merges expressions with the same loose key, see
+	// expr.LooseKey, e.g. a - b + c and a + c - b, or a / (b / c) and
+	// a * c / b.
+	DedupAggressive Dedup = "aggressive"
+)
+
+// DedupLevels lists every supported Dedup, from the strictest to the
+// loosest.
+var DedupLevels = []Dedup{DedupNone, DedupSyntactic, DedupCanonical, DedupAggressive}
+
+// ParseDedup converts a name such as "aggressive" into a Dedup.
+func ParseDedup(name string) (Dedup, error) {
+	dedup := Dedup(name)
+	if !slices.Contains(DedupLevels, dedup) {
+		return "", fmt.Errorf("unknown deduplication level '%s'", name)
+	}
+	return dedup, nil
+}
+
+// merges reports whether d merges at least what level does.
+func (d Dedup) merges(level Dedup) bool {
+	if d == "" {
+		d = DedupCanonical
+	}
+	return slices.Index(DedupLevels, d) >= slices.Index(DedupLevels, level)
+}
@@ -0,0 +1,129 @@
+package solver_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/x0root/24Solver/expr"
+	"github.com/x0root/24Solver/solver"
+)
+
+func TestDedupCounts(t *testing.T) {
+	tests := []struct {
+		hand []float64
+		// want is the number of solutions at each of solver.DedupLevels.
+		want [4]int
+	}{
+		{[]float64{3, 3, 8, 8}, [4]int{1, 1, 1, 1}},
+		{[]float64{1, 1, 1, 1}, [4]int{0, 0, 0, 0}},
+		{[]float64{4, 4, 4, 4}, [4]int{24, 3, 1, 1}},
+		{[]float64{6, 6, 6, 6}, [4]int{26, 3, 3, 2}},
+		{[]float64{1, 5, 5, 5}, [4]int{2, 2, 1, 1}},
+		{[]float64{1, 2, 3, 4}, [4]int{292, 86, 25, 3}},
+		{[]float64{2, 3, 4, 5}, [4]int{40, 24, 6, 2}},
+	}
+	for _, tt := range tests {
+		for i, level := range solver.DedupLevels {
+			solutions, err := solver.New(solver.WithDedup(level)).Solve(tt.hand)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(solutions) != tt.want[i] {
+				t.Errorf("%v with -dedup %s: %d solution(s), want %d", tt.hand, level, len(solutions), tt.want[i])
+			}
+		}
+	}
+}
+
+// TestUniqueCountsUnpruned checks the pruning of the search against a
+// plain search that tries every ordered pair of terms with every
+// operator, for every standard hand. Every solution must be one of the
+// plain search, and every canonical solution of the plain search must be
+// found, unless it prints the same as one that was, which Solve merges
+// as the same formula.
+func TestUniqueCountsUnpruned(t *testing.T) {
+	if testing.Short() {
+		t.Skip("searches all 1820 standard hands")
+	}
+	slv := solver.New()
+	for _, hand := range solver.StandardHands() {
+		solutions, err := slv.Solve(hand)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := unprunedKeys(hand, 24)
+		keys, formulas := make(map[string]bool), make(map[string]bool)
+		for _, solution := range solutions {
+			keys[solution.Key], formulas[solution.Formula] = true, true
+			if want[solution.Key] == nil {
+				t.Errorf("%v: %s is not a solution of the unpruned search", hand, solution.Formula)
+			}
+		}
+	missing:
+		for key, printed := range want {
+			if keys[key] {
+				continue
+			}
+			for _, formula := range printed {
+				if formulas[formula] {
+					continue missing
+				}
+			}
+			t.Errorf("%v: %s was not found", hand, printed[0])
+		}
+	}
+}
+
+// unprunedKeys returns the canonical keys of every tree over nums that
+// makes target with + - * /, evaluated on floats, each with the formulas
+// of its trees.
+func unprunedKeys(nums []float64, target float64) map[string][]string {
+	keys := make(map[string][]string)
+	var search func(nodes []*expr.Node)
+	search = func(nodes []*expr.Node) {
+		if len(nodes) == 1 {
+			if math.Abs(nodes[0].Value-target) < 1e-9 {
+				key := expr.CanonicalKey(nodes[0])
+				keys[key] = append(keys[key], nodes[0].MinimalInfix())
+			}
+			return
+		}
+		for i := range nodes {
+			for j := range nodes {
+				if i == j {
+					continue
+				}
+				l, r := nodes[i], nodes[j]
+				rest := make([]*expr.Node, 0, len(nodes)-1)
+				for k, node := range nodes {
+					if k != i && k != j {
+						rest = append(rest, node)
+					}
+				}
+				for _, op := range []string{"+", "-", "*", "/"} {
+					var value float64
+					switch op {
+					case "+":
+						value = l.Value + r.Value
+					case "-":
+						value = l.Value - r.Value
+					case "*":
+						value = l.Value * r.Value
+					case "/":
+						if math.Abs(r.Value) < 1e-9 {
+							continue
+						}
+						value = l.Value / r.Value
+					}
+					search(append(rest, &expr.Node{Op: op, Value: value, Left: l, Right: r}))
+				}
+			}
+		}
+	}
+	leaves := make([]*expr.Node, len(nums))
+	for i, num := range nums {
+		leaves[i] = &expr.Node{Value: num}
+	}
+	search(leaves)
+	return keys
+}
@@ -0,0 +1,583 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/x0root/24Solver/expr"
+)
+
+// engine runs the search on one arithmetic backend. It lets a Solver hold
+// a typedEngine of any element type.
+type engine interface {
+	search(ctx context.Context, s *Solver, nums []float64, targets []float64, variants map[string][]string, stats *Stats, yield func(Solution) bool) error
+	// reaches reports whether tree evaluates to target on the backend.
+	reaches(tree *Node, target float64) bool
+	// nearest returns how far from the target of s the closest value nums
+	// can make is. See Solver.Closest.
+	nearest(ctx context.Context, s *Solver, nums []float64) (float64, bool, error)
+	// reachable returns an expression for every whole number from low to
+	// high that nums can make, by value. See Solver.Reachable.
+	reachable(ctx context.Context, s *Solver, nums []float64, low, high int) (map[int]*Node, error)
+}
+
+// typedEngine is the search over values of type T.
+type typedEngine[T any] struct {
+	ar Arithmetic[T]
+}
+
+func (e typedEngine[T]) reaches(tree *Node, target float64) bool {
+	return reaches(e.ar, tree, target)
+}
+
+// reaches evaluates tree on ar and reports whether it makes target. It is
+// false when an operation is undefined on ar.
+func reaches[T any](ar Arithmetic[T], tree *Node, target float64) bool {
+	want, ok := ar.FromFloat(target)
+	if !ok {
+		return false
+	}
+	value, ok := evaluate(ar, tree)
+	return ok && ar.Equal(value, want)
+}
+
+// evaluate computes the value of tree from its leaves on ar.
+func evaluate[T any](ar Arithmetic[T], tree *Node) (T, bool) {
+	if tree.IsLeaf() {
+		return ar.FromFloat(tree.Value)
+	}
+	l, ok := evaluate(ar, tree.Left)
+	if !ok {
+		return l, false
+	}
+	if tree.IsUnary() {
+		return calculateUnary(ar, l, tree.Op)
+	}
+	r, ok := evaluate(ar, tree.Right)
+	if !ok {
+		return r, false
+	}
+	return calculate(ar, l, r, tree.Op)
+}
+
+// term is a value still available to the search, together with the
+// expression that produced it.
+type term[T any] struct {
+	value T
+	node  *Node
+}
+
+// errStop ends a search early without reporting an error to the caller.
+var errStop = errors.New("stop search")
+
+// searchState is the state shared by every step of one search.
+type searchState[T any] struct {
+	ctx context.Context
+	s   *Solver
+	ar  Arithmetic[T]
+	// targets are the values searched for, and goals the same on the
+	// backend, leaving out those it cannot represent.
+	targets []float64
+	goals   []T
+	// low and high bound the values that count as reaching the single
+	// target when ranged is set, see WithTolerance.
+	ranged    bool
+	low, high float64
+	zero      T
+	// found counts the solutions reported for each target.
+	found []int
+	// seenHashes maps the canonical hash of every solution that has been
+	// claimed to its key and the Key of the solution that claimed it,
+	// several if hashes collide; seenKeys maps mirror keys and formulas
+	// to the Key of the solution that claimed them.
+	seenHashes map[uint64][]seenTree
+	seenKeys   map[string]string
+	variants   map[string][]string
+	yield      func(Solution) bool
+	// stats receives the counts of the search; counts holds those made
+	// outside any worker.
+	stats  *Stats
+	counts counters
+	// hand counts the hands searched so far of hands, and start is when
+	// the search began, for WithProgress.
+	hand, hands int
+	start       time.Time
+}
+
+// search runs the search for targets without input validation, passing
+// each unique solution to yield as soon as it is found. All the targets
+// are looked for in one traversal of the expressions. It stops early when
+// yield returns false, when every target has reached the solution limit,
+// or when ctx is done, in which case it returns ctx.Err(). When variants
+// is not nil, every other distinct formula found for a reported solution,
+// starting with its own, is added under the solution's Key. The work done
+// is added to stats. With WithConcatenation or WithSubsets, every hand of
+// hands is searched in turn. With WithNegation, each hand is first
+// searched without it, so a solution that needs no negation is reported
+// in a form without one, e.g. 8 / (3 - 8 / 3) rather than
+// -(8 / (8 / 3 - 3)).
+func (e typedEngine[T]) search(ctx context.Context, s *Solver, nums []float64, targets []float64, variants map[string][]string, stats *Stats, yield func(Solution) bool) error {
+	st, terms, ok := e.newState(ctx, s, nums, targets, stats)
+	if !ok {
+		return nil
+	}
+	st.variants = variants
+	st.yield = yield
+	defer st.counts.addTo(stats)
+	passes := []*Solver{s}
+	if s.negation {
+		plain := *s
+		plain.negation = false
+		passes = []*Solver{&plain, s}
+	}
+	hands := s.hands(nums)
+	st.hands, st.start = len(hands)*len(passes), time.Now()
+	for i, hand := range hands {
+		if i > 0 {
+			if s.tolerance > 0 && len(hand) > maxRangeNumbers {
+				// Reuse can make hands too large to be stored, which
+				// a target range needs.
+				continue
+			}
+			if terms, ok = e.terms(hand); !ok {
+				continue
+			}
+		}
+		for k, pass := range passes {
+			st.s, st.hand = pass, i*len(passes)+k+1
+			var err error
+			if len(terms) >= subsetSearchMin || s.subsetSearch {
+				err = st.subsets(terms)
+			} else {
+				err = st.trees(terms)
+			}
+			if err == errStop {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// newState prepares a search of nums for targets, converting both to the
+// backend. It returns false when a number or every target cannot be
+// represented, in which case nothing can be found. A target range only
+// has a single target.
+func (e typedEngine[T]) newState(ctx context.Context, s *Solver, nums []float64, targets []float64, stats *Stats) (*searchState[T], []term[T], bool) {
+	var goals []T
+	for _, target := range targets {
+		if goal, ok := e.ar.FromFloat(target); ok {
+			goals = append(goals, goal)
+		}
+	}
+	// A target range is checked on float values, so its midpoint need
+	// not be representable.
+	if len(goals) == 0 && s.tolerance == 0 {
+		return nil, nil, false
+	}
+	terms, ok := e.terms(nums)
+	if !ok {
+		return nil, nil, false
+	}
+	zero, _ := e.ar.FromFloat(0)
+
+	st := &searchState[T]{
+		ctx:        ctx,
+		s:          s,
+		ar:         e.ar,
+		targets:    targets,
+		goals:      goals,
+		ranged:     s.tolerance > 0,
+		low:        targets[0] - s.tolerance - defaultEpsilon,
+		high:       targets[0] + s.tolerance + defaultEpsilon,
+		zero:       zero,
+		found:      make([]int, len(targets)),
+		seenHashes: make(map[uint64][]seenTree),
+		seenKeys:   make(map[string]string),
+		stats:      stats,
+	}
+	return st, terms, true
+}
+
+// terms converts nums to the backend, reporting false when one of them
+// cannot be represented.
+func (e typedEngine[T]) terms(nums []float64) ([]term[T], bool) {
+	terms := make([]term[T], len(nums))
+	for i, num := range nums {
+		value, ok := e.ar.FromFloat(num)
+		if !ok {
+			return nil, false
+		}
+		terms[i] = term[T]{value: value, node: &Node{Value: num}}
+	}
+	return terms, true
+}
+
+// trees searches every expression tree over terms. The first combination
+// step is fanned out across the workers, one task per pair of terms and
+// operator. With unary operators, it is done for every way of applying
+// them to the numbers, see leafForms.
+func (st *searchState[T]) trees(terms []term[T]) error {
+	starts := [][]term[T]{terms}
+	if st.s.hasUnary() {
+		starts = st.leafForms(terms)
+	}
+	type move struct {
+		start, i, j int
+		op          string
+	}
+	var moves []move
+	for start, terms := range starts {
+		for i := 0; i < len(terms); i++ {
+			for j := i + 1; j < len(terms); j++ {
+				if repeatsPair(terms, i, j) {
+					st.counts.pruned += int64(len(st.s.operators))
+					continue
+				}
+				for _, op := range st.s.operators {
+					moves = append(moves, move{start, i, j, op})
+				}
+			}
+		}
+	}
+	if len(moves) == 0 {
+		sc := newScratch[T](len(terms))
+		defer sc.counts.addTo(st.stats)
+		for _, terms := range starts {
+			if err := st.combine(st.ctx, terms, sc, st.report); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	scratches := make([]*scratch[T], workerCount(st.s.workers))
+	defer func() {
+		for _, sc := range scratches {
+			if sc != nil {
+				sc.counts.addTo(st.stats)
+			}
+		}
+	}()
+	return inOrder(st.ctx, len(moves), len(scratches), func(ctx context.Context, worker, task int, emit func(*Node)) error {
+		if scratches[worker] == nil {
+			scratches[worker] = newScratch[T](len(terms))
+		}
+		m := moves[task]
+		return st.merge(ctx, starts[m.start], scratches[worker], m.i, m.j, m.op, func(node *Node) error {
+			emit(node.Clone())
+			return nil
+		})
+	}, st.report, st.taskDone(len(moves)))
+}
+
+// scratch is the memory one worker reuses for every step of its search:
+// for each number of terms k below the hand size, terms[k] holds the k
+// terms of a step and nodes[k] the node of the expression just built for
+// it. Nothing in it survives the step, so trees that reach the target
+// must be cloned. counts is what the worker counted.
+type scratch[T any] struct {
+	terms  [][]term[T]
+	nodes  []Node
+	counts counters
+}
+
+// newScratch returns a scratch for a hand of n numbers.
+func newScratch[T any](n int) *scratch[T] {
+	sc := &scratch[T]{terms: make([][]term[T], n), nodes: make([]Node, n)}
+	for k := range sc.terms {
+		sc.terms[k] = make([]term[T], k)
+	}
+	return sc
+}
+
+// combine tries every way of replacing two of terms by the result of one
+// operator applied to them, in either order, and recurses until a single
+// term is left, passing the tree of each one that reaches the target to
+// hit. Every binary expression tree over the inputs is reached this way.
+// The trees passed to hit are built in sc and only valid during the call.
+func (st *searchState[T]) combine(ctx context.Context, terms []term[T], sc *scratch[T], hit func(*Node) error) error {
+	if len(terms) == 1 {
+		if st.hits(terms[0].value) {
+			return hit(terms[0].node)
+		}
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for i := 0; i < len(terms); i++ {
+		for j := i + 1; j < len(terms); j++ {
+			if repeatsPair(terms, i, j) {
+				sc.counts.pruned += int64(len(st.s.operators))
+				continue
+			}
+			for _, op := range st.s.operators {
+				if err := st.merge(ctx, terms, sc, i, j, op, hit); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// repeatsPair reports whether combining terms[i] and terms[j] would only
+// repeat an earlier pair of the same step, because both pairs hold the
+// same two expressions. With repeated numbers, e.g. 8 8 3 3, this skips
+// most of the work without losing any tree.
+func repeatsPair[T any](terms []term[T], i, j int) bool {
+	for k := 0; k <= i; k++ {
+		for l := k + 1; l < len(terms); l++ {
+			if k == i && l == j {
+				return false
+			}
+			if sameTree(terms[k].node, terms[i].node) && sameTree(terms[l].node, terms[j].node) ||
+				sameTree(terms[k].node, terms[j].node) && sameTree(terms[l].node, terms[i].node) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameTree reports whether a and b are the same expression.
+func sameTree(a, b *Node) bool {
+	if a == b {
+		return true
+	}
+	if a.Value != b.Value {
+		return false
+	}
+	if a.IsLeaf() || b.IsLeaf() {
+		return a.IsLeaf() && b.IsLeaf()
+	}
+	return a.Op == b.Op && sameTree(a.Left, b.Left) && sameTree(a.Right, b.Right)
+}
+
+// merge replaces terms[i] and terms[j] by terms[i] op terms[j], then by
+// terms[j] op terms[i], and continues the search from each, and from each
+// unary form of each. The shorter list and the new node live in the slots
+// of sc for len(terms)-1 terms, which deeper steps never touch.
+func (st *searchState[T]) merge(ctx context.Context, terms []term[T], sc *scratch[T], i, j int, op string, hit func(*Node) error) error {
+	// The result takes the place of terms[i], so the remaining terms keep
+	// the order they were given in.
+	rest := sc.terms[len(terms)-1]
+	node := &sc.nodes[len(terms)-1]
+	copy(rest, terms[:j])
+	copy(rest[j:], terms[j+1:])
+	for k, pair := range [2][2]term[T]{{terms[i], terms[j]}, {terms[j], terms[i]}} {
+		if k == 1 && st.mirrorsOnly(op) {
+			sc.counts.pruned++
+			break
+		}
+		a, b := pair[0], pair[1]
+		value, ok := st.calculate(&sc.counts, a.value, b.value, op)
+		if !ok {
+			continue
+		}
+		*node = Node{Op: op, Value: st.ar.Float(value), Left: a.node, Right: b.node}
+		rest[i] = term[T]{value: value, node: node}
+		if err := st.combine(ctx, rest, sc, hit); err != nil {
+			return err
+		}
+		if !st.s.hasUnary() {
+			continue
+		}
+		err := st.eachUnary(term[T]{value: value, node: node}, &sc.counts, func(u term[T]) error {
+			rest[i] = u
+			return st.combine(ctx, rest, sc, hit)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hits reports whether value reaches one of the targets, or falls in the
+// target range.
+func (st *searchState[T]) hits(value T) bool {
+	if !st.ranged {
+		return slices.ContainsFunc(st.goals, func(goal T) bool {
+			return st.ar.Equal(value, goal)
+		})
+	}
+	f := st.ar.Float(value)
+	return f >= st.low && f <= st.high
+}
+
+// mirrorsOnly reports whether b op a, after a op b has been tried, can only
+// find mirror images of the trees already found, which the canonical key
+// merges anyway. Variants list those mirror images, so they are still
+// searched when variants are collected, as they are when WithDedup does
+// not merge them.
+func (st *searchState[T]) mirrorsOnly(op string) bool {
+	return commutative(op) && st.variants == nil && st.s.dedup.merges(DedupCanonical)
+}
+
+// calculate is calculate on the backend of the search, counting the
+// operation in c. It also reports false for a result the rules of the
+// Solver do not admit.
+func (st *searchState[T]) calculate(c *counters, a, b T, op string) (T, bool) {
+	c.evaluated++
+	value, ok := calculate(st.ar, a, b, op)
+	if !ok && (op == "/" || op == "%") && st.ar.Equal(b, st.zero) {
+		c.divByZero++
+	}
+	return value, ok && st.admits(value)
+}
+
+// admits reports whether value may be an intermediate value under the
+// rules of WithIntegerDivision, WithNonNegative and WithMaxIntermediate.
+func (st *searchState[T]) admits(value T) bool {
+	s := st.s
+	if !s.integerDivision && !s.nonNegative && s.maxIntermediate == 0 {
+		return true
+	}
+	f := st.ar.Float(value)
+	return (!s.integerDivision || isWhole(f)) && (!s.nonNegative || f >= 0) &&
+		(s.maxIntermediate == 0 || math.Abs(f) <= s.maxIntermediate)
+}
+
+// newSolution describes an evaluated tree as a Solution.
+func newSolution(tree *Node) Solution {
+	return Solution{Formula: tree.MinimalInfix(), Value: tree.Value, Tree: tree, Key: expr.CanonicalKey(tree), Steps: tree.Steps(), Meta: analyze(tree)}
+}
+
+// report deduplicates a tree that reaches the target as WithDedup asks and
+// passes it to yield if it is a new solution. It returns errStop once the
+// search should end.
+func (st *searchState[T]) report(tree *Node) error {
+	s := st.s
+	seenKeys := st.seenKeys
+	canonical := s.dedup.merges(DedupCanonical)
+	// Most trees repeat a solution already found, so they are recognized
+	// by hash before any key or formula is built.
+	hash := expr.CanonicalHash(tree)
+	if seen, ok := st.seenHashes[hash]; ok && canonical {
+		// Two keys can share a hash; only the same key is a duplicate.
+		key := expr.CanonicalKey(tree)
+		if i := slices.IndexFunc(seen, func(seen seenTree) bool { return seen.key == key }); i >= 0 {
+			st.stats.Duplicates++
+			if st.variants != nil {
+				st.addVariant(seen[i].owner, tree.MinimalInfix())
+			}
+			return nil
+		}
+	}
+	solution := newSolution(tree)
+	key := solution.Key
+	// A rejected variant leaves its key unclaimed so an equivalent one
+	// that passes the filters can still be reported.
+	if !MustUse(s.required...)(solution) || !accepts(s.filters, solution) {
+		return nil
+	}
+	target := targetOf(solution.Value, st.targets)
+	if s.maxSolutions > 0 && st.found[target] >= s.maxSolutions {
+		return nil
+	}
+	st.claim(hash, key, key)
+	if s.dedup.merges(DedupAggressive) {
+		loose := "loose:" + expr.LooseKey(tree)
+		if owner, ok := seenKeys[loose]; ok {
+			st.stats.Duplicates++
+			st.claim(hash, key, owner)
+			st.addVariant(owner, solution.Formula)
+			return nil
+		}
+		seenKeys[loose] = key
+	}
+	if s.mergeMirrors && canonical {
+		mirror := "mirror:" + expr.MirrorKey(tree)
+		if owner, ok := seenKeys[mirror]; ok {
+			st.stats.Duplicates++
+			st.claim(hash, key, owner)
+			st.addVariant(owner, solution.Formula)
+			return nil
+		}
+		seenKeys[mirror] = key
+	}
+	// Dropping redundant parentheses can make distinct trees print the
+	// same, e.g. (2*3)/(1/4) and 2*(3/(1/4)); keep only the first.
+	if owner, ok := seenKeys["formula:"+solution.Formula]; ok && s.dedup.merges(DedupSyntactic) {
+		st.stats.Duplicates++
+		st.claim(hash, key, owner)
+		return nil
+	}
+	seenKeys["formula:"+solution.Formula] = key
+	if st.variants != nil && canonical {
+		dropVariant(st.variants, solution.Formula)
+		st.variants[key] = []string{solution.Formula}
+	}
+
+	if !st.yield(solution) {
+		return errStop
+	}
+	st.found[target]++
+	if s.maxSolutions > 0 && !slices.ContainsFunc(st.found, func(n int) bool { return n < s.maxSolutions }) {
+		return errStop
+	}
+	return nil
+}
+
+// seenTree is a claimed tree of a hash in seenHashes: its canonical key
+// and the Key of the solution it counts toward.
+type seenTree struct {
+	key, owner string
+}
+
+// claim records that the trees of hash and key count toward the solution
+// with Key owner.
+func (st *searchState[T]) claim(hash uint64, key, owner string) {
+	seen := st.seenHashes[hash]
+	for i := range seen {
+		if seen[i].key == key {
+			seen[i].owner = owner
+			return
+		}
+	}
+	st.seenHashes[hash] = append(seen, seenTree{key, owner})
+}
+
+// targetOf returns the index of the target in targets nearest value, the
+// one a solution of that value reaches.
+func targetOf(value float64, targets []float64) int {
+	best := 0
+	for i, target := range targets {
+		if math.Abs(value-target) < math.Abs(value-targets[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// addVariant records formula as a variant of the solution with Key owner,
+// unless variants are not collected, the formula is already known, or
+// another solution prints as it: trees of different keys can print the
+// same, e.g. 6 + (1 - 4) and (6 + 1) - 4.
+func (st *searchState[T]) addVariant(owner, formula string) {
+	variants := st.variants
+	if variants == nil || slices.Contains(variants[owner], formula) {
+		return
+	}
+	if claimed, ok := st.seenKeys["formula:"+formula]; ok && claimed != owner {
+		return
+	}
+	variants[owner] = append(variants[owner], formula)
+}
+
+// dropVariant takes formula, which a new solution prints as, out of the
+// variants recorded for the solutions before it.
+func dropVariant(variants map[string][]string, formula string) {
+	for owner, formulas := range variants {
+		if i := slices.Index(formulas, formula); i > 0 {
+			variants[owner] = slices.Delete(formulas, i, i+1)
+		}
+	}
+}
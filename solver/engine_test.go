@@ -0,0 +1,118 @@
+package solver_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// Forbidding every binary operator used to crash the search on a nil
+// scratch; it is now rejected up front.
+func TestSolveWithoutOperators(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []solver.Option
+	}{
+		{"forbidden", []solver.Option{solver.WithForbiddenOperators("+", "-", "*", "/")}},
+		{"forbidden with unary", []solver.Option{solver.WithForbiddenOperators("+", "-", "*", "/"), solver.WithSqrt(1), solver.WithFactorial(4)}},
+		{"none given", []solver.Option{solver.WithOperators()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slv := solver.New(tt.opts...)
+			if _, err := slv.Solve([]float64{8, 8, 3, 3}); !errors.Is(err, solver.ErrNoOperators) {
+				t.Errorf("Solve error = %v, want %v", err, solver.ErrNoOperators)
+			}
+			if _, _, err := slv.Closest([]float64{8, 8, 3, 3}); !errors.Is(err, solver.ErrNoOperators) {
+				t.Errorf("Closest error = %v, want %v", err, solver.ErrNoOperators)
+			}
+		})
+	}
+}
+
+// Negation only adds solutions: those that need none keep the form they
+// have without it.
+func TestNegationKeepsPlainSolutions(t *testing.T) {
+	for _, hand := range [][]float64{{3, 3, 8, 8}, {1, 2, 3, 8}, {1, 5, 5, 5}, {4, 4, 10, 10}} {
+		plain, err := solver.New().Solve(hand)
+		if err != nil {
+			t.Fatal(err)
+		}
+		negated, err := solver.New(solver.WithNegation(true)).Solve(hand)
+		if err != nil {
+			t.Fatal(err)
+		}
+		formulas := make(map[string]string)
+		for _, solution := range negated {
+			formulas[solution.Key] = solution.Formula
+		}
+		for _, solution := range plain {
+			if formulas[solution.Key] != solution.Formula {
+				t.Errorf("%v: %s with negation, %s without", hand, formulas[solution.Key], solution.Formula)
+			}
+		}
+	}
+}
+
+// Reuse expands the hand past the size a target range can search; those
+// hands used to crash the search on parts that were never stored.
+func TestReuseWithTolerance(t *testing.T) {
+	slv := solver.New(solver.WithReuse(2), solver.WithTolerance(0.5))
+	solutions, err := slv.Solve([]float64{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(solutions) == 0 {
+		t.Fatal("no solutions for 1 2 3 4")
+	}
+	for _, solution := range solutions {
+		if value, err := solution.Tree.Eval(); err != nil || value < 23.5-1e-9 || value > 24.5+1e-9 {
+			t.Errorf("%s = %g, %v, want 23.5 to 24.5", solution.Formula, value, err)
+		}
+	}
+}
+
+// The midpoint 22.5 of the range is no int64, which left the subset
+// search of five numbers without a goal to index.
+func TestInt64TargetRange(t *testing.T) {
+	slv := solver.New(solver.WithArithmetic[int64](solver.Int64Arithmetic{}), solver.WithTargetRange(20, 25))
+	solutions, err := slv.Solve([]float64{1, 2, 3, 4, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(solutions) == 0 {
+		t.Fatal("no solutions for 1 2 3 4 5")
+	}
+	for _, solution := range solutions {
+		if value, err := solution.Tree.Eval(); err != nil || value < 20 || value > 25 {
+			t.Errorf("%s = %g, %v, want 20 to 25", solution.Formula, value, err)
+		}
+	}
+}
+
+// Trees of different keys can print the same, so a formula reached again
+// as a duplicate of one solution may be another solution; it must not be
+// listed among the variants too.
+func TestVariantsAreNotSolutions(t *testing.T) {
+	for _, dedup := range []solver.Dedup{solver.DedupCanonical, solver.DedupAggressive} {
+		slv := solver.New(solver.WithVariants(true), solver.WithDedup(dedup))
+		for _, hand := range [][]float64{{4, 6, 8, 1}, {1, 2, 3, 4}, {2, 3, 4, 5}, {6, 6, 6, 6}, {1, 2, 3, 4, 5}} {
+			solutions, err := slv.Solve(hand)
+			if err != nil {
+				t.Fatal(err)
+			}
+			formulas := make(map[string]bool)
+			for _, solution := range solutions {
+				formulas[solution.Formula] = true
+			}
+			for _, solution := range solutions {
+				for _, variant := range solution.Variants {
+					if variant != solution.Formula && formulas[variant] {
+						t.Errorf("%v, dedup %v: %s is listed as a variant of %s but is a solution itself", hand, dedup, variant, solution.Formula)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrWrongCount is returned when a hand does not have exactly 4 numbers.
+var ErrWrongCount = errors.New("you must enter exactly 4 numbers")
+
+// ErrNoOperators is returned by Solve when WithOperators or
+// WithForbiddenOperators leave no binary operator to combine the numbers
+// with.
+var ErrNoOperators = errors.New("no operators left to combine the numbers with")
+
+// ErrHandSize is returned by Solve when a hand has fewer than MinNumbers
+// or more than MaxNumbers numbers, and by ParseHandN when it does not have
+// the size asked for. It matches ErrWrongCount with errors.Is.
+type ErrHandSize struct {
+	Count int // how many numbers the hand has
+	Want  int // the size asked for, or 0 for any allowed size
+}
+
+func (e *ErrHandSize) Error() string {
+	return e.Localize(fmt.Sprintf)
+}
+
+// Localize returns the message of e with its format passed through
+// sprintf, e.g. to translate it; Error is Localize(fmt.Sprintf). The other
+// errors of the package with values in their message have it too.
+func (e *ErrHandSize) Localize(sprintf func(format string, args ...any) string) string {
+	if e.Want > 0 {
+		return sprintf("you must enter exactly %d numbers, found %d", e.Want, e.Count)
+	}
+	return sprintf("hands must have from %d to %d numbers, found %d", MinNumbers, MaxNumbers, e.Count)
+}
+
+func (e *ErrHandSize) Is(target error) bool {
+	return target == ErrWrongCount
+}
+
+// ErrNotANumber is returned when part of the input cannot be read as a number.
+type ErrNotANumber struct {
+	Token string // the offending part of the input
+}
+
+func (e *ErrNotANumber) Error() string {
+	return e.Localize(fmt.Sprintf)
+}
+
+// Localize is ErrHandSize.Localize for e.
+func (e *ErrNotANumber) Localize(sprintf func(format string, args ...any) string) string {
+	return sprintf("'%s' is not a valid number", e.Token)
+}
+
+// ErrOutOfRange is returned when a number is not an integer between Min
+// and Max.
+type ErrOutOfRange struct {
+	Value    float64
+	Min, Max float64
+	// Fractional is set when fractions are allowed, see WithFractions. A
+	// Min of 0 then means any positive number.
+	Fractional bool
+}
+
+func (e *ErrOutOfRange) Error() string {
+	return e.Localize(fmt.Sprintf)
+}
+
+// Localize is ErrHandSize.Localize for e.
+func (e *ErrOutOfRange) Localize(sprintf func(format string, args ...any) string) string {
+	switch {
+	case e.Fractional && e.Min == 0 && math.IsInf(e.Max, 1):
+		return sprintf("numbers must be greater than 0, found: %g", e.Value)
+	case e.Fractional && e.Min == 0:
+		return sprintf("numbers must be greater than 0 and at most %g, found: %g", e.Max, e.Value)
+	case e.Fractional && math.IsInf(e.Max, 1):
+		return sprintf("numbers must be finite, found: %g", e.Value)
+	case e.Fractional:
+		return sprintf("numbers must be from %g to %g, found: %g", e.Min, e.Max, e.Value)
+	case math.IsInf(e.Min, -1) && math.IsInf(e.Max, 1):
+		return sprintf("numbers must be whole numbers, found: %g", e.Value)
+	case math.IsInf(e.Max, 1):
+		return sprintf("numbers must be whole numbers from %g up, found: %g", e.Min, e.Value)
+	}
+	return sprintf("numbers must be whole numbers from %g to %g, found: %g", e.Min, e.Max, e.Value)
+}
@@ -0,0 +1,48 @@
+package solver
+
+// Filter reports whether a solution should be kept.
+type Filter func(Solution) bool
+
+// IntegerOnly keeps solutions whose intermediate values are all whole
+// numbers.
+func IntegerOnly(s Solution) bool {
+	return !s.Meta.Fractional
+}
+
+// NoDivision keeps solutions that do not divide.
+func NoDivision(s Solution) bool {
+	return !s.Meta.Uses("/")
+}
+
+// MustUse keeps solutions that use every one of ops.
+func MustUse(ops ...string) Filter {
+	return func(s Solution) bool {
+		for _, op := range ops {
+			if !s.Meta.Uses(op) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// FilterSolutions returns the solutions accepted by every filter.
+func FilterSolutions(solutions []Solution, filters ...Filter) []Solution {
+	var kept []Solution
+	for _, solution := range solutions {
+		if accepts(filters, solution) {
+			kept = append(kept, solution)
+		}
+	}
+	return kept
+}
+
+// accepts reports whether every filter keeps solution.
+func accepts(filters []Filter, solution Solution) bool {
+	for _, filter := range filters {
+		if !filter(solution) {
+			return false
+		}
+	}
+	return true
+}
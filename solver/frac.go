@@ -0,0 +1,197 @@
+package solver
+
+import (
+	"math"
+	"math/big"
+)
+
+// Frac is an exact fraction, the value type of FracArithmetic. Fractions
+// whose numerator and denominator fit in an int64 are stored inline; larger
+// ones fall back to a big.Rat, so no operation ever loses precision.
+type Frac struct {
+	num, den int64    // in lowest terms with den > 0, unless big is set
+	big      *big.Rat // set instead of num and den when they would overflow
+}
+
+// newFrac returns num/den in lowest terms. den must not be zero.
+func newFrac(num, den int64) Frac {
+	if num == math.MinInt64 || den == math.MinInt64 {
+		return fracFromRat(big.NewRat(num, den))
+	}
+	if den < 0 {
+		num, den = -num, -den
+	}
+	if g := gcd(num, den); g > 1 {
+		num, den = num/g, den/g
+	}
+	return Frac{num: num, den: den}
+}
+
+// fracFromRat stores r inline when it fits.
+func fracFromRat(r *big.Rat) Frac {
+	if r.Num().IsInt64() && r.Denom().IsInt64() {
+		return Frac{num: r.Num().Int64(), den: r.Denom().Int64()}
+	}
+	return Frac{big: r}
+}
+
+// rat returns f as a big.Rat.
+func (f Frac) rat() *big.Rat {
+	if f.big != nil {
+		return f.big
+	}
+	return big.NewRat(f.num, f.den)
+}
+
+// Float64 returns the nearest float64 to f.
+func (f Frac) Float64() float64 {
+	if f.big != nil {
+		v, _ := f.big.Float64()
+		return v
+	}
+	return float64(f.num) / float64(f.den)
+}
+
+// String returns f as "a/b", or "a" for a whole number.
+func (f Frac) String() string {
+	return f.rat().RatString()
+}
+
+// gcd returns the greatest common divisor of |a| and b, for b > 0.
+func gcd(a, b int64) int64 {
+	if a < 0 {
+		a = -a
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// FracArithmetic is the default backend: exact fractions that stay on
+// int64 while they fit and fall back to math/big on overflow. Results are
+// compared exactly, so no tolerance can accept a near miss or reject a
+// hand like 3 3 8 8 that depends on fractional intermediate values.
+type FracArithmetic struct{}
+
+func (FracArithmetic) FromFloat(x float64) (Frac, bool) {
+	if x == math.Trunc(x) && math.Abs(x) < 1<<63 {
+		return Frac{num: int64(x), den: 1}, true
+	}
+	r := simplestRat(x)
+	if r == nil {
+		return Frac{}, false
+	}
+	return fracFromRat(r), true
+}
+
+// simplestRat returns the fraction with the smallest denominator whose
+// nearest float64 is x, e.g. 1/3 for 0.3333333333333333, so a number
+// parsed from "1/3" is searched as exactly 1/3. It falls back to the exact
+// value of x when no fraction with a denominator up to 2^32 fits, and
+// returns nil for infinities and NaN.
+func simplestRat(x float64) *big.Rat {
+	exact := new(big.Rat)
+	if exact.SetFloat64(x) == nil {
+		return nil
+	}
+	// Walk the convergents h/k of the continued fraction of x.
+	limit := new(big.Int).Lsh(big.NewInt(1), 32)
+	h, hPrev := big.NewInt(1), big.NewInt(0)
+	k, kPrev := big.NewInt(0), big.NewInt(1)
+	rest := new(big.Rat).Set(exact)
+	for {
+		a := new(big.Int).Quo(rest.Num(), rest.Denom())
+		if rest.Sign() < 0 && !rest.IsInt() {
+			a.Sub(a, big.NewInt(1)) // floor, not truncation
+		}
+		h, hPrev = new(big.Int).Add(new(big.Int).Mul(a, h), hPrev), h
+		k, kPrev = new(big.Int).Add(new(big.Int).Mul(a, k), kPrev), k
+		if k.Cmp(limit) > 0 {
+			return exact
+		}
+		candidate := new(big.Rat).SetFrac(h, k)
+		if f, _ := candidate.Float64(); f == x {
+			return candidate
+		}
+		rest.Sub(rest, new(big.Rat).SetInt(a))
+		if rest.Sign() == 0 {
+			return exact
+		}
+		rest.Inv(rest)
+	}
+}
+
+func (FracArithmetic) Float(x Frac) float64 { return x.Float64() }
+
+func (FracArithmetic) Add(a, b Frac) (Frac, bool) {
+	if a.big == nil && b.big == nil {
+		if n, ok := crossSum(a, b, false); ok {
+			return n, true
+		}
+	}
+	return fracFromRat(new(big.Rat).Add(a.rat(), b.rat())), true
+}
+
+func (FracArithmetic) Sub(a, b Frac) (Frac, bool) {
+	if a.big == nil && b.big == nil {
+		if n, ok := crossSum(a, b, true); ok {
+			return n, true
+		}
+	}
+	return fracFromRat(new(big.Rat).Sub(a.rat(), b.rat())), true
+}
+
+func (FracArithmetic) Mul(a, b Frac) (Frac, bool) {
+	if a.big == nil && b.big == nil {
+		n, ok1 := Int64Arithmetic{}.Mul(a.num, b.num)
+		d, ok2 := Int64Arithmetic{}.Mul(a.den, b.den)
+		if ok1 && ok2 {
+			return newFrac(n, d), true
+		}
+	}
+	return fracFromRat(new(big.Rat).Mul(a.rat(), b.rat())), true
+}
+
+func (FracArithmetic) Div(a, b Frac) (Frac, bool) {
+	if b.big == nil && b.num == 0 {
+		return Frac{}, false
+	}
+	if a.big == nil && b.big == nil {
+		n, ok1 := Int64Arithmetic{}.Mul(a.num, b.den)
+		d, ok2 := Int64Arithmetic{}.Mul(a.den, b.num)
+		if ok1 && ok2 {
+			return newFrac(n, d), true
+		}
+	}
+	return fracFromRat(new(big.Rat).Quo(a.rat(), b.rat())), true
+}
+
+func (FracArithmetic) Equal(a, b Frac) bool {
+	if a.big == nil && b.big == nil {
+		return a.num == b.num && a.den == b.den
+	}
+	return a.rat().Cmp(b.rat()) == 0
+}
+
+// crossSum returns a+b, or a-b when sub is set, reporting false on int64
+// overflow.
+func crossSum(a, b Frac, sub bool) (Frac, bool) {
+	ints := Int64Arithmetic{}
+	combine := ints.Add
+	if sub {
+		combine = ints.Sub
+	}
+	if a.den == b.den {
+		n, ok := combine(a.num, b.num)
+		return newFrac(n, a.den), ok
+	}
+	l, ok1 := ints.Mul(a.num, b.den)
+	r, ok2 := ints.Mul(b.num, a.den)
+	d, ok3 := ints.Mul(a.den, b.den)
+	n, ok4 := combine(l, r)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return Frac{}, false
+	}
+	return newFrac(n, d), true
+}
@@ -0,0 +1,100 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/x0root/24Solver/expr"
+)
+
+func FuzzParseInput(f *testing.F) {
+	for _, seed := range []string{"3 3 8 8", "3,3,8,8", "3388", "1 2 3", "13 13 13 13", "0 1 2 3", "a b c d", "３ ３ ８ ８", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		if err := checkParseInput(input); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func FuzzSolve(f *testing.F) {
+	f.Add(3.0, 3.0, 8.0, 8.0, 24.0)
+	f.Add(1.0, 5.0, 5.0, 5.0, 24.0)
+	f.Add(1.0, 2.0, 3.0, 4.0, 10.0)
+	f.Add(0.5, 2.0, 7.0, 13.0, 24.0)
+	f.Fuzz(func(t *testing.T, a, b, c, d, target float64) {
+		if err := checkSolve([]float64{a, b, c, d}, target); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// checkParseInput is an entry point for fuzzing ParseInput with arbitrary
+// strings. It returns an error if ParseInput panics, or if it accepts an
+// input without producing a valid hand.
+func checkParseInput(input string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("ParseInput(%q) panicked: %v", input, r)
+		}
+	}()
+	nums, parseErr := ParseInput(input)
+	if parseErr != nil {
+		return nil
+	}
+	if err := New().validateNumbers(nums); err != nil {
+		return fmt.Errorf("ParseInput(%q) accepted an invalid hand: %v", input, err)
+	}
+	return nil
+}
+
+// checkSolve is an entry point for fuzzing the search with arbitrary
+// numbers, bypassing input validation. It returns an error if the search
+// panics, or if any returned formula does not evaluate back to the target
+// when parsed from its printed form.
+func checkSolve(nums []float64, target float64) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("search(%v) panicked: %v", nums, r)
+		}
+	}()
+	// Hands of more than 5 numbers take too long for one fuzz input.
+	if len(nums) < MinNumbers || len(nums) > 5 {
+		return nil
+	}
+	var solutions []Solution
+	New(WithTarget(target)).search(context.Background(), nums, func(solution Solution) bool {
+		solutions = append(solutions, solution)
+		return true
+	})
+	for _, solution := range solutions {
+		tree, err := expr.Parse(solution.Formula)
+		if err != nil {
+			return fmt.Errorf("search(%v) produced unparsable formula %q: %v", nums, solution.Formula, err)
+		}
+		// MinimalInfix prints every number exactly, but Eval works on
+		// floats while the search is exact, so the error allowed grows
+		// with the largest value along the way.
+		value, err := tree.Eval()
+		if err != nil {
+			return fmt.Errorf("search(%v) produced %q, which does not evaluate: %v", nums, solution.Formula, err)
+		}
+		if !isApproximately(value, target, defaultEpsilon*largestValue(solution)) {
+			return fmt.Errorf("search(%v) produced %q, which evaluates to %g, not %g", nums, solution.Formula, value, target)
+		}
+	}
+	return nil
+}
+
+// largestValue returns the largest magnitude among the values of the
+// steps of solution, or 1 if they are all smaller.
+func largestValue(solution Solution) float64 {
+	largest := 1.0
+	for _, step := range solution.Steps {
+		largest = max(largest, math.Abs(step.Left), math.Abs(step.Right), math.Abs(step.Result))
+	}
+	return largest
+}
@@ -0,0 +1,68 @@
+package solver
+
+import (
+	"fmt"
+	"slices"
+)
+
+// hands returns the hands a search of nums covers: nums itself, the hands
+// of concatenations with WithConcatenation, and for each of those every
+// way of using its numbers that WithReuse and WithSubsets allow, up to
+// MaxNumbers numbers. nums comes first and, with WithSubsets, the larger
+// hands next, so that solutions using more numbers are found first. A set
+// of numbers appears once.
+func (s *Solver) hands(nums []float64) [][]float64 {
+	joined := s.concatenations(nums)
+	if !s.subsets && s.maxUses <= 1 {
+		return joined
+	}
+	least, most := 1, max(s.maxUses, 1)
+	if s.subsets {
+		least = 0
+	}
+	var result [][]float64
+	seen := make(map[string]bool)
+	add := func(hand []float64) {
+		sorted := slices.Clone(hand)
+		slices.Sort(sorted)
+		if key := fmt.Sprint(sorted); len(hand) > 0 && !seen[key] {
+			seen[key] = true
+			result = append(result, hand)
+		}
+	}
+	add(nums)
+	for _, hand := range joined {
+		var from func(used []float64, i int)
+		from = func(used []float64, i int) {
+			if i == len(hand) {
+				add(used)
+				return
+			}
+			for uses := least; uses <= most && len(used)+uses <= MaxNumbers; uses++ {
+				next := slices.Clip(used)
+				for range uses {
+					next = append(next, hand[i])
+				}
+				from(next, i+1)
+			}
+		}
+		from(nil, 0)
+	}
+	if s.subsets {
+		// Stable, so nums itself stays first among the hands of its size.
+		slices.SortStableFunc(result, func(a, b []float64) int {
+			return len(b) - len(a)
+		})
+	}
+	return result
+}
+
+// matchesHand reports whether used, sorted, holds the numbers of one of
+// the hands of nums, see hands.
+func (s *Solver) matchesHand(used, nums []float64) bool {
+	return slices.ContainsFunc(s.hands(nums), func(hand []float64) bool {
+		dealt := slices.Clone(hand)
+		slices.Sort(dealt)
+		return slices.Equal(used, dealt)
+	})
+}
@@ -0,0 +1,41 @@
+// Command gentable writes hands.txt, the table of standard hands embedded
+// by the solver package. Run it with go generate in the solver directory
+// after changing the search.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+func main() {
+	f, err := os.Create("hands.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, "# Generated by internal/gentable with go generate; do not edit.")
+	fmt.Fprintln(w, "# Each standard hand, in ascending order, then one solution or - if none.")
+	s := solver.New()
+	for _, hand := range solver.StandardHands() {
+		solution, ok, err := s.First(hand)
+		if err != nil {
+			log.Fatal(err)
+		}
+		formula := "-"
+		if ok {
+			formula = solution.Formula
+		}
+		fmt.Fprintf(w, "%.0f %.0f %.0f %.0f %s\n", hand[0], hand[1], hand[2], hand[3], formula)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,39 @@
+package solver
+
+import (
+	"context"
+	"iter"
+)
+
+// All returns an iterator over the unique ways to make 24 from nums using
+// + - * /. The search runs lazily as the loop consumes solutions, so
+// breaking out of the loop stops it. An invalid hand yields nothing; use
+// Solve to learn why.
+func All(nums []float64) iter.Seq[Solution] {
+	return New().All(nums)
+}
+
+// All returns an iterator over the unique solutions for nums, found lazily
+// as the loop consumes them and so in discovery order rather than the
+// sorted order of Solve. An invalid hand yields nothing.
+func (s *Solver) All(nums []float64) iter.Seq[Solution] {
+	return func(yield func(Solution) bool) {
+		if s.validate(nums) != nil {
+			return
+		}
+		s.search(context.Background(), nums, yield)
+	}
+}
+
+// First returns the first solution the search finds for nums and stops
+// right there, which makes it the cheap way to ask whether a hand is
+// solvable at all. The bool is false if there is no solution.
+func (s *Solver) First(nums []float64) (Solution, bool, error) {
+	if err := s.validate(nums); err != nil {
+		return Solution{}, false, err
+	}
+	for solution := range s.All(nums) {
+		return solution, true, nil
+	}
+	return Solution{}, false, nil
+}
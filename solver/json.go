@@ -0,0 +1,49 @@
+package solver
+
+import "encoding/json"
+
+// solutionJSON is the wire form of a Solution. Its field names are part of
+// the package's stable output.
+type solutionJSON struct {
+	Formula  string   `json:"formula"`
+	Value    float64  `json:"value"`
+	Key      string   `json:"key"`
+	Tree     *Node    `json:"tree"`
+	Meta     Metadata `json:"meta"`
+	Variants []string `json:"variants,omitempty"`
+}
+
+// MarshalJSON encodes the solution as an object with its formula, value,
+// canonical key, expression tree and metadata, plus its variants when
+// they were collected.
+func (s Solution) MarshalJSON() ([]byte, error) {
+	return json.Marshal(solutionJSON{
+		Formula:  s.Formula,
+		Value:    s.Value,
+		Key:      s.Key,
+		Tree:     s.Tree,
+		Meta:     s.Meta,
+		Variants: s.Variants,
+	})
+}
+
+// UnmarshalJSON decodes a solution written by MarshalJSON, rebuilding its
+// steps from the tree.
+func (s *Solution) UnmarshalJSON(data []byte) error {
+	var wire solutionJSON
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*s = Solution{
+		Formula:  wire.Formula,
+		Value:    wire.Value,
+		Tree:     wire.Tree,
+		Key:      wire.Key,
+		Meta:     wire.Meta,
+		Variants: wire.Variants,
+	}
+	if s.Tree != nil {
+		s.Steps = s.Tree.Steps()
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package solver
+
+import (
+	"math"
+	"slices"
+)
+
+// Metadata summarizes the arithmetic of a solution, for filtering and
+// difficulty analysis.
+type Metadata struct {
+	// Operators lists the distinct operators used, in + - * / ^ % order, then the
+	// unary ones.
+	Operators []string `json:"operators"`
+	// Fractional reports whether any intermediate value is not an integer.
+	Fractional bool `json:"fractional"`
+	// Negative reports whether any intermediate value is below zero.
+	Negative bool `json:"negative"`
+	// Numbers is how many numbers the solution uses: fewer than the hand
+	// has only with WithSubsets, or WithConcatenation, which joins numbers.
+	Numbers int `json:"numbers"`
+	// MaxIntermediate is the largest magnitude of any intermediate value,
+	// the final result included.
+	MaxIntermediate float64 `json:"max_intermediate"`
+}
+
+// analyze computes the metadata of an evaluated tree.
+func analyze(tree *Node) Metadata {
+	var meta Metadata
+	used := make(map[string]bool)
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		if node.IsLeaf() {
+			meta.Numbers++
+			return
+		}
+		visit(node.Left)
+		if node.Right != nil {
+			visit(node.Right)
+		}
+		used[node.Op] = true
+		if !isWhole(node.Value) {
+			meta.Fractional = true
+		}
+		if node.Value < 0 {
+			meta.Negative = true
+		}
+		meta.MaxIntermediate = math.Max(meta.MaxIntermediate, math.Abs(node.Value))
+	}
+	visit(tree)
+	for _, op := range slices.Concat(supported, unaryOperators) {
+		if used[op] {
+			meta.Operators = append(meta.Operators, op)
+		}
+	}
+	return meta
+}
+
+// isWhole reports whether v is an integer, allowing for float rounding
+// such as 8 / (1/3) = 23.999999999999996.
+func isWhole(v float64) bool {
+	return math.Abs(v-math.Round(v)) < defaultEpsilon*math.Max(1, math.Abs(v))
+}
+
+// Uses reports whether the solution uses op.
+func (m Metadata) Uses(op string) bool {
+	return slices.Contains(m.Operators, op)
+}
@@ -0,0 +1,51 @@
+package solver_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/x0root/24Solver/expr"
+	"github.com/x0root/24Solver/solver"
+)
+
+func TestMergeMirrors(t *testing.T) {
+	tests := []struct {
+		hand          []float64
+		plain, merged int
+	}{
+		{[]float64{1, 1, 3, 13}, 2, 1}, // (1-3)*(1-13) and (3-1)*(13-1)
+		{[]float64{1, 1, 4, 9}, 2, 1},
+		{[]float64{1, 1, 5, 7}, 3, 2},
+		{[]float64{1, 2, 5, 9}, 4, 3},
+		{[]float64{3, 3, 8, 8}, 1, 1},
+		{[]float64{4, 4, 4, 4}, 1, 1},
+		{[]float64{6, 6, 6, 6}, 3, 3},
+		{[]float64{1, 1, 1, 1}, 0, 0},
+	}
+	for _, tt := range tests {
+		plain, err := solver.New().Solve(tt.hand)
+		if err != nil {
+			t.Fatal(err)
+		}
+		merged, err := solver.New(solver.WithMergeMirrors(true)).Solve(tt.hand)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plain) != tt.plain || len(merged) != tt.merged {
+			t.Errorf("%v: %d solution(s), %d with mirrors merged, want %d and %d", tt.hand, len(plain), len(merged), tt.plain, tt.merged)
+		}
+		// Merging only drops solutions, and never two that are not
+		// mirrors of each other.
+		keys := make(map[string]bool)
+		for _, solution := range merged {
+			if !slices.ContainsFunc(plain, func(s solver.Solution) bool { return s.Key == solution.Key }) {
+				t.Errorf("%v: merged solution %s is not among the unmerged ones", tt.hand, solution.Formula)
+			}
+			mirror := expr.MirrorKey(solution.Tree)
+			if keys[mirror] {
+				t.Errorf("%v: %s is the mirror of another merged solution", tt.hand, solution.Formula)
+			}
+			keys[mirror] = true
+		}
+	}
+}
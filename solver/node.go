@@ -0,0 +1,91 @@
+package solver
+
+import (
+	"math"
+
+	"github.com/x0root/24Solver/expr"
+)
+
+// Node represents a node in an expression tree. See expr.Node.
+type Node = expr.Node
+
+// commutative reports whether a op b always equals b op a.
+func commutative(op string) bool {
+	return op == "+" || op == "*"
+}
+
+// calculate applies op to a and b using the arithmetic backend ar.
+func calculate[T any](ar Arithmetic[T], a, b T, op string) (T, bool) {
+	switch op {
+	case "+":
+		return ar.Add(a, b)
+	case "-":
+		return ar.Sub(a, b)
+	case "*":
+		return ar.Mul(a, b)
+	case "/":
+		return ar.Div(a, b)
+	case "^":
+		return power(ar, a, b)
+	case "%":
+		return remainder(ar, a, b)
+	}
+	var zero T
+	return zero, false
+}
+
+// maxExponent and maxPower bound ^, which would otherwise make numbers too
+// large for any backend from a handful of small ones, e.g. 9^(9^9).
+const (
+	maxExponent = 64
+	maxPower    = 1e15
+)
+
+// power computes a^b for a whole exponent b of at most maxExponent by
+// repeated multiplication, so the result is exact on exact backends. It
+// reports false for other exponents, for 0^0 and 0 to a negative power,
+// and for results beyond maxPower in magnitude.
+func power[T any](ar Arithmetic[T], a, b T) (T, bool) {
+	var zero T
+	e := ar.Float(b)
+	if e != math.Trunc(e) || math.Abs(e) > maxExponent {
+		return zero, false
+	}
+	base := ar.Float(a)
+	if base == 0 && e <= 0 {
+		return zero, false
+	}
+	if e*math.Log2(math.Abs(base)) > math.Log2(maxPower) {
+		return zero, false
+	}
+	result, ok := ar.FromFloat(1)
+	if !ok {
+		return zero, false
+	}
+	for range int(math.Abs(e)) {
+		if result, ok = ar.Mul(result, a); !ok {
+			return zero, false
+		}
+	}
+	if e < 0 {
+		one, _ := ar.FromFloat(1)
+		return ar.Div(one, result)
+	}
+	return result, true
+}
+
+// remainder computes a % b for whole numbers, with the sign of a as Go's %
+// has it, e.g. -7 % 3 = -1. It reports false when b is 0 or either number
+// is not whole.
+func remainder[T any](ar Arithmetic[T], a, b T) (T, bool) {
+	var zero T
+	x, y := ar.Float(a), ar.Float(b)
+	if y == 0 || x != math.Trunc(x) || y != math.Trunc(y) {
+		return zero, false
+	}
+	return ar.FromFloat(math.Mod(x, y))
+}
+
+func isApproximately(value, target, epsilon float64) bool {
+	return math.Abs(value-target) < epsilon
+}
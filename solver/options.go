@@ -0,0 +1,262 @@
+package solver
+
+import "math"
+
+// Option customizes a Solver created by New.
+type Option func(*Solver)
+
+// WithTarget sets the value solutions must reach. The default is 24.
+func WithTarget(target float64) Option {
+	return func(s *Solver) {
+		s.target = target
+	}
+}
+
+// WithTolerance accepts every solution whose value is within tolerance of
+// the target, e.g. anything from 23.5 to 24.5 for WithTolerance(0.5).
+// Hands of more than 6 numbers cannot be searched for a range.
+func WithTolerance(tolerance float64) Option {
+	return func(s *Solver) {
+		s.tolerance = tolerance
+	}
+}
+
+// WithTargetRange accepts every solution whose value is from low to high.
+// It sets the target to the middle of the range and the tolerance to half
+// its width, so a later WithTarget moves the range.
+func WithTargetRange(low, high float64) Option {
+	return func(s *Solver) {
+		s.target = (low + high) / 2
+		s.tolerance = (high - low) / 2
+	}
+}
+
+// WithEpsilon runs the search on float64 values, counting a result as
+// reaching the target when it is within epsilon of it, instead of on the
+// default exact fractions. It replaces any earlier WithArithmetic.
+func WithEpsilon(epsilon float64) Option {
+	return WithArithmetic[float64](Float64Arithmetic{Epsilon: epsilon})
+}
+
+// WithOperators sets the operators the search may use, a subset of
+// + - * / ^ %. Solve reports an error for any other operator.
+func WithOperators(ops ...string) Option {
+	return func(s *Solver) {
+		s.operators = append([]string(nil), ops...)
+	}
+}
+
+// WithSqrt allows square roots of numbers and sub-expressions, nested up to
+// depth deep: sqrt(sqrt(16)) needs a depth of 2. Roots the backend cannot
+// represent, such as the root of 2 on the default exact fractions, are
+// skipped. Zero, the default, allows none.
+func WithSqrt(depth int) Option {
+	return func(s *Solver) {
+		s.sqrtDepth = max(depth, 0)
+	}
+}
+
+// WithFactorial allows the factorial n! of numbers and sub-expressions
+// whose value is a whole number n of at most limit, e.g. 4! = 24 with a
+// limit of 4 or more. The limit is capped at 20; zero, the default, allows
+// no factorials.
+func WithFactorial(limit int) Option {
+	return func(s *Solver) {
+		s.factorialMax = min(max(limit, 0), maxFactorial)
+	}
+}
+
+// WithNegation allows negating numbers and sub-expressions, e.g.
+// -(1 - 2) * 3 * 8.
+func WithNegation(negation bool) Option {
+	return func(s *Solver) {
+		s.negation = negation
+	}
+}
+
+// WithConcatenation allows writing adjacent single digits of the hand, in
+// the order dealt, as one number before any operator applies, e.g.
+// 12 + 3 * 4 for 1 2 3 4. Computed values are never joined. The order of
+// the hand then matters, for Solve as for Verify.
+func WithConcatenation(concatenation bool) Option {
+	return func(s *Solver) {
+		s.concatenation = concatenation
+	}
+}
+
+// WithMaxSolutions stops the search once n unique solutions have been
+// found. Zero, the default, means no limit.
+func WithMaxSolutions(n int) Option {
+	return func(s *Solver) {
+		s.maxSolutions = n
+	}
+}
+
+// WithMergeMirrors also merges solutions that are sign-mirrors of each
+// other, e.g. (a-b)*(c-d) and (b-a)*(d-c).
+func WithMergeMirrors(merge bool) Option {
+	return func(s *Solver) {
+		s.mergeMirrors = merge
+	}
+}
+
+// WithDedup sets which solutions are merged as the same one, from
+// DedupNone, which reports every expression found, to DedupAggressive;
+// DedupCanonical is the default. Solution.Key stays the canonical key at
+// every level, so solutions that are not merged may share it.
+func WithDedup(dedup Dedup) Option {
+	return func(s *Solver) {
+		s.dedup = dedup
+	}
+}
+
+// WithIntegerDivision allows a division only when it divides evenly, as
+// many school versions of the game have it: no intermediate value may be a
+// fraction, so 8 / (3 - 8 / 3) is out. Unlike the IntegerOnly filter it is
+// a rule of the search, which never builds a fraction, and Verify rejects
+// answers that break it.
+func WithIntegerDivision(integer bool) Option {
+	return func(s *Solver) {
+		s.integerDivision = integer
+	}
+}
+
+// WithNonNegative rejects every expression with a negative intermediate
+// value, a common house rule for children: (5 - 1) * (8 - 2) is fine, but
+// (1 - 5) * (2 - 8) is not, though its result is 24. Like
+// WithIntegerDivision it is a rule of the search and of Verify.
+func WithNonNegative(nonNegative bool) Option {
+	return func(s *Solver) {
+		s.nonNegative = nonNegative
+	}
+}
+
+// WithMaxIntermediate rejects every expression with an intermediate
+// value, the result included, beyond limit in magnitude, e.g. 100 for
+// mental arithmetic. The numbers of the hand are not intermediate values.
+// Zero, the default, means no cap. Like WithIntegerDivision it is a rule
+// of the search and of Verify.
+func WithMaxIntermediate(limit float64) Option {
+	return func(s *Solver) {
+		s.maxIntermediate = math.Max(limit, 0)
+	}
+}
+
+// WithSubsets lets solutions use any selection of the numbers of the hand
+// instead of all of them, as in Countdown. Metadata.Numbers says how many
+// each one uses, and solutions using more come first.
+func WithSubsets(subsets bool) Option {
+	return func(s *Solver) {
+		s.subsets = subsets
+	}
+}
+
+// WithReuse lets each number of the hand be used up to uses times instead
+// of exactly once, as some apps play the game, though still at least once
+// unless WithSubsets is set too. A solution uses at most MaxNumbers
+// numbers. Values below 2 mean once, the default. Reuse widens the search
+// a lot: four numbers used up to twice make 16 hands of 4 to 8 numbers.
+// Closest and Reachable leave out the hands of more than 6 numbers.
+func WithReuse(uses int) Option {
+	return func(s *Solver) {
+		s.maxUses = uses
+	}
+}
+
+// WithRequiredOperators keeps only solutions that use every one of ops,
+// binary or unary, e.g. "/" to drill division. Unlike MustUse, it is part
+// of the configuration, so the results can still be cached. Solve reports
+// an error when one of ops is not allowed.
+func WithRequiredOperators(ops ...string) Option {
+	return func(s *Solver) {
+		s.required = append(s.required, ops...)
+	}
+}
+
+// WithForbiddenOperators keeps the search from using any of ops, whatever
+// the other options allow: forbidding "*" with the default operators
+// searches with + - / only, and forbidding "sqrt", "!" or "neg" turns off
+// WithSqrt, WithFactorial or WithNegation.
+func WithForbiddenOperators(ops ...string) Option {
+	return func(s *Solver) {
+		s.forbidden = append(s.forbidden, ops...)
+	}
+}
+
+// WithFilters keeps only solutions accepted by every filter. Filters run
+// during the search, so rejected solutions do not count towards
+// WithMaxSolutions and an equivalent variant that passes can be reported
+// instead.
+func WithFilters(filters ...Filter) Option {
+	return func(s *Solver) {
+		s.filters = append(s.filters, filters...)
+	}
+}
+
+// WithVariants makes Solve keep, in Solution.Variants, the equivalent
+// formulas that were merged into each unique solution.
+func WithVariants(collect bool) Option {
+	return func(s *Solver) {
+		s.variants = collect
+	}
+}
+
+// WithWorkers sets how many goroutines one search may use. Zero, the
+// default, means GOMAXPROCS. Results do not depend on it.
+func WithWorkers(n int) Option {
+	return func(s *Solver) {
+		s.workers = n
+	}
+}
+
+// WithLargeNumbers accepts any positive integer in a hand, not only the
+// card values 1 to 13.
+func WithLargeNumbers(allow bool) Option {
+	return func(s *Solver) {
+		s.largeNumbers = allow
+	}
+}
+
+// WithZeroAndNegatives also accepts 0 and negative numbers in a hand, e.g.
+// -3 0 5 8. Divisions by an intermediate 0 are skipped as usual.
+func WithZeroAndNegatives(allow bool) Option {
+	return func(s *Solver) {
+		s.negatives = allow
+	}
+}
+
+// WithFractions also accepts numbers that are not whole in a hand, e.g. 0.5
+// or 1/3, within the range of card values or WithLargeNumbers. Numbers are
+// searched as the simplest fraction that rounds to them, so 1/3 is exact.
+func WithFractions(allow bool) Option {
+	return func(s *Solver) {
+		s.fractions = allow
+	}
+}
+
+// WithDecimalComma makes ParseHandN read a comma as the decimal separator,
+// as many locales write it, so "3,5 2 1 4" is 3.5 2 1 4; the numbers of a
+// hand are then separated by spaces or semicolons instead. Numbers that
+// are not whole still need WithFractions.
+func WithDecimalComma(decimalComma bool) Option {
+	return func(s *Solver) {
+		s.decimalComma = decimalComma
+	}
+}
+
+// WithCache makes Solve answer hands already in c from it and store the
+// hands it solves there. Solvers with filters do not use the cache.
+func WithCache(c *Cache) Option {
+	return func(s *Solver) {
+		s.cache = c
+	}
+}
+
+// WithArithmetic runs the search on the given numeric backend, e.g.
+// Int64Arithmetic{} for integers only or RatArithmetic{} for math/big,
+// instead of the default exact fractions.
+func WithArithmetic[T any](ar Arithmetic[T]) Option {
+	return func(s *Solver) {
+		s.engine = typedEngine[T]{ar}
+	}
+}
@@ -0,0 +1,191 @@
+package solver
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/x0root/24Solver/expr"
+)
+
+// cardValues maps card notation to the value of the card.
+var cardValues = map[string]float64{"A": 1, "T": 10, "J": 11, "Q": 12, "K": 13}
+
+// splitHand splits a hand of count numbers, or of any allowed size when
+// count is 0, into its tokens, after reading its digits and full-width
+// characters as expr.Normalize does. Tokens are separated by commas, or
+// with decimalComma by semicolons, or by whitespace; input with neither is
+// read as one token per character. With decimalComma the commas of the
+// tokens become decimal points.
+func splitHand(input string, count int, decimalComma bool) []string {
+	input = strings.TrimSpace(expr.Normalize(input))
+	separator := ","
+	if decimalComma {
+		separator = ";"
+	}
+	var parts []string
+	n := utf8.RuneCountInString(input)
+	if strings.Contains(input, separator) {
+		parts = strings.Split(input, separator)
+	} else if strings.Contains(input, " ") {
+		parts = strings.Fields(input)
+	} else if (n == count || count == 0 && n >= MinNumbers && n <= MaxNumbers) && isCompact(input) {
+		for _, char := range input {
+			parts = append(parts, string(char))
+		}
+	} else {
+		parts = strings.Fields(input)
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+		if decimalComma {
+			parts[i] = strings.ReplaceAll(parts[i], ",", ".")
+		}
+	}
+	return parts
+}
+
+// isCompact reports whether input can be a hand written without
+// separators, one card per character, rather than one number like "1/2".
+func isCompact(input string) bool {
+	for _, char := range input {
+		if !unicode.IsLetter(char) && !unicode.IsDigit(char) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseInput parses a hand typed by the user. The numbers may be separated
+// by spaces or commas, or written as four digits with no separator, and
+// may be anything from 1 to 13. Digits of any script and full-width
+// characters are accepted, e.g. "３ ３ ８ ８".
+// Errors are ErrWrongCount, *ErrNotANumber or *ErrOutOfRange, so callers
+// can tell them apart with errors.Is and errors.As.
+func ParseInput(input string) ([]float64, error) {
+	parts := splitHand(input, 4, false)
+	if len(parts) != 4 {
+		return nil, ErrWrongCount
+	}
+	var nums []float64
+	for _, part := range parts {
+		num, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, &ErrNotANumber{Token: part}
+		}
+		if err := checkRange(num, 1, 13); err != nil {
+			return nil, err
+		}
+		nums = append(nums, num)
+	}
+	return nums, nil
+}
+
+// ParseHand is like ParseInput but also accepts card notation: A, T, J, Q
+// and K (in either case) stand for 1, 10, 11, 12 and 13, and numbers may
+// be anything from 1 to 13. "A 5 T K", "a,5,10,k" and "A5TK" all parse to
+// the same hand.
+func ParseHand(input string) ([]float64, error) {
+	return ParseHandN(input, 4)
+}
+
+// ParseHandN is like ParseHand but for hands of count numbers, or of any
+// size from MinNumbers to MaxNumbers when count is 0, e.g. "1 2 3" or
+// "A2345". A hand of the wrong size is an *ErrHandSize, which matches
+// ErrWrongCount.
+func ParseHandN(input string, count int) ([]float64, error) {
+	return New().ParseHandN(input, count)
+}
+
+// ParseHandN is like the package's ParseHandN but accepts the numbers s
+// accepts, e.g. any positive integer with WithLargeNumbers, written as s
+// reads them, e.g. with a decimal comma with WithDecimalComma.
+func (s *Solver) ParseHandN(input string, count int) ([]float64, error) {
+	parts := splitHand(input, count, s.decimalComma)
+	if count == 4 && len(parts) != 4 {
+		return nil, ErrWrongCount
+	}
+	if count > 0 && len(parts) != count || len(parts) < MinNumbers || len(parts) > MaxNumbers {
+		return nil, &ErrHandSize{Count: len(parts), Want: count}
+	}
+	var nums []float64
+	for _, part := range parts {
+		num, ok := cardValues[strings.ToUpper(part)]
+		if !ok && s.fractions && strings.Contains(part, "/") {
+			r, ok := new(big.Rat).SetString(part)
+			if !ok {
+				return nil, &ErrNotANumber{Token: part}
+			}
+			num, _ = r.Float64()
+		} else if !ok {
+			var err error
+			if num, err = strconv.ParseFloat(part, 64); err != nil {
+				return nil, &ErrNotANumber{Token: part}
+			}
+		}
+		if err := s.checkNumber(num); err != nil {
+			return nil, err
+		}
+		nums = append(nums, num)
+	}
+	return nums, nil
+}
+
+// checkRange reports an *ErrOutOfRange unless num is an integer in [lo, hi].
+func checkRange(num, lo, hi float64) error {
+	if num < lo || num > hi || num != math.Floor(num) || math.IsInf(num, 0) {
+		return &ErrOutOfRange{Value: num, Min: lo, Max: hi}
+	}
+	return nil
+}
+
+// The search combines any number of values, but the number of expressions
+// grows so fast that hands are limited to this range.
+const (
+	MinNumbers = 2
+	MaxNumbers = 8
+)
+
+// validateNumbers checks that nums is a legal hand for s: MinNumbers to
+// MaxNumbers numbers accepted by checkNumber.
+func (s *Solver) validateNumbers(nums []float64) error {
+	if len(nums) < MinNumbers || len(nums) > MaxNumbers {
+		return &ErrHandSize{Count: len(nums)}
+	}
+	for _, num := range nums {
+		if err := s.checkNumber(num); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNumber reports an *ErrOutOfRange unless s accepts num: a card value
+// from 1 to 13, or with WithLargeNumbers any positive integer. With
+// WithZeroAndNegatives, the negatives of those and 0 are accepted too, and
+// with WithFractions any number between them, e.g. 0.5.
+func (s *Solver) checkNumber(num float64) error {
+	hi := 13.0
+	if s.largeNumbers {
+		hi = math.Inf(1)
+	}
+	lo := 1.0
+	if s.negatives {
+		lo = -hi
+	}
+	if !s.fractions {
+		return checkRange(num, lo, hi)
+	}
+	if s.negatives && num >= lo && num <= hi || num > 0 && num <= hi {
+		if !math.IsInf(num, 0) {
+			return nil
+		}
+	}
+	if !s.negatives {
+		lo = 0
+	}
+	return &ErrOutOfRange{Value: num, Min: lo, Max: hi, Fractional: true}
+}
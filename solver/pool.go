@@ -0,0 +1,100 @@
+package solver
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// workerCount returns how many goroutines a search may use: n, or
+// GOMAXPROCS when n is not positive.
+func workerCount(n int) int {
+	if n > 0 {
+		return n
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// taskResult is what one task of inOrder emitted.
+type taskResult struct {
+	nodes []*Node
+	err   error
+	done  chan struct{}
+}
+
+// inOrder runs tasks 0 to n-1 on up to workers goroutines and passes the
+// trees each task emits to consume, task by task in index order, so the
+// outcome does not depend on scheduling. run is told which worker runs it,
+// for per-worker state. Once consume returns an error the remaining tasks
+// are cancelled and inOrder returns that error; an error from a task is
+// returned when its turn comes, after the trees it emitted before failing.
+// done, when not nil, is called with the index of each task once its trees
+// have been consumed.
+func inOrder(ctx context.Context, n, workers int, run func(ctx context.Context, worker, task int, emit func(*Node)) error, consume func(*Node) error, done func(task int)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	results := make([]taskResult, n)
+	for i := range results {
+		results[i].done = make(chan struct{})
+	}
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := range min(workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				k := int(next.Add(1)) - 1
+				if k >= n {
+					return
+				}
+				r := &results[k]
+				r.err = run(ctx, w, k, func(node *Node) { r.nodes = append(r.nodes, node) })
+				close(r.done)
+			}
+		}()
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	for i := range results {
+		r := &results[i]
+		<-r.done
+		for _, node := range r.nodes {
+			if err := consume(node); err != nil {
+				return err
+			}
+		}
+		r.nodes = nil
+		if r.err != nil {
+			return r.err
+		}
+		if done != nil {
+			done(i)
+		}
+	}
+	return nil
+}
+
+// forEach calls fn for 0 to n-1 on up to workers goroutines and waits for
+// all of them.
+func forEach(n, workers int, fn func(i int)) {
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= n {
+					return
+				}
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,40 @@
+package solver
+
+import "time"
+
+// Progress is how far a search has got, as passed to the function of
+// WithProgress.
+type Progress struct {
+	// Hand is the hand being searched, counted from 1, of the Hands the
+	// search goes through: more than one with WithConcatenation,
+	// WithSubsets or WithReuse. WithNegation searches each hand twice,
+	// first without negations, and counts each pass as a hand.
+	Hand, Hands int
+	// Done of the Total tasks of the hand are finished. A task is one
+	// first step of the search, e.g. 3 + 8 for 3 3 8 8, or for hands of 5
+	// or more numbers one way of splitting the hand in two.
+	Done, Total int
+	// Elapsed is the time since the search began.
+	Elapsed time.Duration
+}
+
+// WithProgress calls fn as a search goes, after every task it finishes,
+// so a long search can show how far it has got. fn is called from the
+// goroutine that called Solve, in order, and should return quickly.
+// Answers from the cache, and Closest and Reachable, report no progress.
+func WithProgress(fn func(Progress)) Option {
+	return func(s *Solver) {
+		s.progress = fn
+	}
+}
+
+// taskDone returns the function inOrder calls as each of the total tasks
+// of the current hand is finished, or nil without WithProgress.
+func (st *searchState[T]) taskDone(total int) func(task int) {
+	if st.s.progress == nil {
+		return nil
+	}
+	return func(task int) {
+		st.s.progress(Progress{Hand: st.hand, Hands: st.hands, Done: task + 1, Total: total, Elapsed: time.Since(st.start)})
+	}
+}
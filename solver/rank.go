@@ -0,0 +1,115 @@
+package solver
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Elegance scores how easy a solution is to follow, out of 10: every
+// solution starts at 10 and loses 4 points for fractional intermediate
+// values, 2 for using division and 1 for each distinct operator. Higher is
+// more elegant.
+func (s Solution) Elegance() int {
+	score := 10 - len(s.Meta.Operators)
+	if s.Meta.Fractional {
+		score -= 4
+	}
+	if s.Meta.Uses("/") {
+		score -= 2
+	}
+	return score
+}
+
+// SortOrder selects how Sort orders solutions.
+type SortOrder string
+
+const (
+	// SortCanonical orders by canonical key, the order Solve returns.
+	SortCanonical SortOrder = "canonical"
+	// SortElegance puts the most elegant solutions first.
+	SortElegance SortOrder = "elegance"
+	// SortAlpha orders by formula text.
+	SortAlpha SortOrder = "alpha"
+	// SortOps puts solutions using fewer distinct operators first.
+	SortOps SortOrder = "ops"
+)
+
+// SortOrders lists every supported SortOrder.
+var SortOrders = []SortOrder{SortCanonical, SortElegance, SortAlpha, SortOps}
+
+// ParseSortOrder converts a name such as "elegance" into a SortOrder.
+func ParseSortOrder(name string) (SortOrder, error) {
+	order := SortOrder(name)
+	if !slices.Contains(SortOrders, order) {
+		return "", fmt.Errorf("unknown sort order '%s'", name)
+	}
+	return order, nil
+}
+
+// Sort orders solutions in place. Ties are broken by canonical key and
+// formula so the result is deterministic.
+func Sort(solutions []Solution, order SortOrder) {
+	sortSolutions(solutions)
+	var by func(a, b Solution) int
+	switch order {
+	case SortElegance:
+		by = func(a, b Solution) int { return cmp.Compare(b.Elegance(), a.Elegance()) }
+	case SortAlpha:
+		by = func(a, b Solution) int { return strings.Compare(a.Formula, b.Formula) }
+	case SortOps:
+		by = func(a, b Solution) int { return cmp.Compare(len(a.Meta.Operators), len(b.Meta.Operators)) }
+	default:
+		return
+	}
+	slices.SortStableFunc(solutions, by)
+}
+
+// Difficulty grades how hard a hand is to solve, see Rate.
+type Difficulty string
+
+const (
+	// DifficultyEasy hands have many solutions, one of them without
+	// division or fractions using at most two distinct operators, e.g.
+	// 1 2 3 4.
+	DifficultyEasy Difficulty = "easy"
+	// DifficultyMedium hands are neither easy nor hard.
+	DifficultyMedium Difficulty = "medium"
+	// DifficultyHard hands have few solutions, none of them simple, e.g.
+	// 3 3 8 8, whose only solution needs a fraction.
+	DifficultyHard Difficulty = "hard"
+)
+
+// Difficulties lists every Difficulty, from easy to hard.
+var Difficulties = []Difficulty{DifficultyEasy, DifficultyMedium, DifficultyHard}
+
+// ParseDifficulty converts a name such as "hard" into a Difficulty.
+func ParseDifficulty(name string) (Difficulty, error) {
+	difficulty := Difficulty(name)
+	if !slices.Contains(Difficulties, difficulty) {
+		return "", fmt.Errorf("unknown difficulty '%s'", name)
+	}
+	return difficulty, nil
+}
+
+// Rate grades a hand by its solutions, as Solve returns them: easy with at
+// least 6 solutions, one of them with an Elegance of 8 or more, hard with
+// at most 3, none above 5, and medium otherwise. Of the solvable standard
+// hands, nearly half are easy and about one in fifteen hard. It reports false
+// when there are no solutions.
+func Rate(solutions []Solution) (Difficulty, bool) {
+	if len(solutions) == 0 {
+		return "", false
+	}
+	best := slices.MaxFunc(solutions, func(a, b Solution) int {
+		return cmp.Compare(a.Elegance(), b.Elegance())
+	}).Elegance()
+	switch {
+	case best >= 8 && len(solutions) >= 6:
+		return DifficultyEasy, true
+	case best <= 5 && len(solutions) <= 3:
+		return DifficultyHard, true
+	}
+	return DifficultyMedium, true
+}
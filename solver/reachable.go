@@ -0,0 +1,122 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+)
+
+// Reachable returns one solution for every whole number from low to high
+// that nums can make, in increasing order of value, ignoring the target:
+// for four 4s from 1 to 10 it starts 4 / (4 + 4 - 4) = 1 and
+// 4 * 4 / (4 + 4) = 2. Like Closest, it supports hands of up to 6
+// numbers. Filters and WithRequiredOperators do not apply, since only one
+// expression per value is kept.
+func (s *Solver) Reachable(nums []float64, low, high int) ([]Solution, error) {
+	return s.ReachableContext(context.Background(), nums, low, high)
+}
+
+// ReachableContext is like Reachable but stops early when ctx is done.
+func (s *Solver) ReachableContext(ctx context.Context, nums []float64, low, high int) ([]Solution, error) {
+	if err := s.validate(nums); err != nil {
+		return nil, err
+	}
+	if len(nums) > maxRangeNumbers {
+		return nil, fmt.Errorf("reachable values can only be listed for hands of at most %d numbers", maxRangeNumbers)
+	}
+	if low > high {
+		return nil, fmt.Errorf("empty range %d..%d", low, high)
+	}
+	trees, err := s.engine.reachable(ctx, s, nums, low, high)
+	if err != nil {
+		return nil, err
+	}
+	solutions := make([]Solution, 0, len(trees))
+	for _, value := range slices.Sorted(maps.Keys(trees)) {
+		solutions = append(solutions, newSolution(trees[value]))
+	}
+	return solutions, nil
+}
+
+func (e typedEngine[T]) reachable(ctx context.Context, s *Solver, nums []float64, low, high int) (map[int]*Node, error) {
+	found := make(map[int]*Node)
+	st, terms, ok := e.newState(ctx, s, nums, []float64{s.target}, &Stats{})
+	if !ok {
+		return found, nil
+	}
+	for i, hand := range s.hands(nums) {
+		if i > 0 {
+			if len(hand) > maxRangeNumbers {
+				// Reuse can make hands too large to be stored.
+				continue
+			}
+			if terms, ok = e.terms(hand); !ok {
+				continue
+			}
+		}
+		if err := st.reachable(terms, low, high, found); err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// reachable adds to found an expression for every whole number from low
+// to high that terms can make and found has none for yet, combining the
+// values of the two parts of every split of the hand as nearest does.
+func (st *searchState[T]) reachable(terms []term[T], low, high int, found map[int]*Node) error {
+	wanted := func(value T) (int, bool) {
+		f := st.ar.Float(value)
+		if !isWhole(f) {
+			return 0, false
+		}
+		n := int(math.Round(f))
+		return n, n >= low && n <= high && found[n] == nil
+	}
+	record := func(t term[T]) error {
+		if n, ok := wanted(t.value); ok {
+			found[n] = t.node.Clone()
+		}
+		return nil
+	}
+	if len(terms) == 1 {
+		record(terms[0])
+		return st.eachUnary(terms[0], &st.counts, record)
+	}
+	values, err := st.fill(terms, sameNumbers(terms))
+	if err != nil {
+		return err
+	}
+	for _, sp := range splits(1<<len(terms) - 1) {
+		if err := st.ctx.Err(); err != nil {
+			return err
+		}
+		for _, p := range values[sp.small].terms {
+			for _, q := range values[sp.large].terms {
+				for _, op := range st.s.operators {
+					for k, pair := range [2][2]term[T]{{p, q}, {q, p}} {
+						if k == 1 && commutative(op) {
+							break
+						}
+						value, ok := st.calculate(&st.counts, pair[0].value, pair[1].value, op)
+						if !ok {
+							continue
+						}
+						n, want := wanted(value)
+						if !want && !st.s.hasUnary() {
+							continue
+						}
+						t := term[T]{value: value, node: &Node{Op: op, Value: st.ar.Float(value), Left: pair[0].node, Right: pair[1].node}}
+						if want {
+							found[n] = t.node.Clone()
+						}
+						st.eachUnary(t, &st.counts, record)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
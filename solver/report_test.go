@@ -0,0 +1,35 @@
+package solver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/x0root/24Solver/expr"
+)
+
+// A solution whose hash was claimed by another key must still be reported,
+// and only a tree of the same key counted as its duplicate.
+func TestReportHashCollision(t *testing.T) {
+	st, _, ok := typedEngine[Frac]{FracArithmetic{}}.newState(context.Background(), New(), []float64{3, 3, 8, 8}, []float64{24}, &Stats{})
+	if !ok {
+		t.Fatal("no search state for 3 3 8 8")
+	}
+	var reported []string
+	st.yield = func(solution Solution) bool {
+		reported = append(reported, solution.Formula)
+		return true
+	}
+	tree, err := expr.Parse("8 / (3 - 8 / 3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.claim(expr.CanonicalHash(tree), "(colliding key)", "(colliding key)")
+	for range 2 {
+		if err := st.report(tree); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(reported) != 1 || st.stats.Duplicates != 1 {
+		t.Errorf("reported %q with %d duplicate(s), want the solution once and 1 duplicate", reported, st.stats.Duplicates)
+	}
+}
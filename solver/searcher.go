@@ -0,0 +1,55 @@
+package solver
+
+import "context"
+
+// Searcher is a search algorithm that finds every unique way to reach
+// target from nums. Different algorithms can be swapped in behind it and
+// benchmarked against each other.
+type Searcher interface {
+	FindAll(nums []float64, target float64) ([]Solution, error)
+}
+
+// Solver is the default Searcher. On hands of up to 4 numbers it replaces
+// any two of the values left by the result of an operator applied to
+// them, in either order, until one value is left, which reaches every
+// expression tree over the hand. From 5 numbers on it runs the subset
+// search of SubsetSearcher instead, which is what makes hands of up to
+// MaxNumbers finish in reasonable time.
+var _ Searcher = (*Solver)(nil)
+
+// SubsetSearcher returns the Searcher that runs the subset search on
+// hands of every size, with the configuration of s. It computes the values
+// every part of the hand can make, keeping one expression per value, and
+// combines the values of the two parts of every split of the hand. So it
+// can miss formulas Solver finds on small hands, when several expressions
+// for one part make the same value, but it does far less work on large
+// ones.
+func (s *Solver) SubsetSearcher() Searcher {
+	sub := *s
+	sub.subsetSearch = true
+	return subsetSearcher{&sub}
+}
+
+// subsetSearcher is the Searcher of SubsetSearcher.
+type subsetSearcher struct {
+	s *Solver
+}
+
+func (d subsetSearcher) FindAll(nums []float64, target float64) ([]Solution, error) {
+	return d.s.FindAll(nums, target)
+}
+
+// FindAll is like Solve but searches for target instead of the target the
+// Solver was created with.
+func (s *Solver) FindAll(nums []float64, target float64) ([]Solution, error) {
+	if err := s.validate(nums); err != nil {
+		return nil, err
+	}
+	var solutions []Solution
+	err := s.engine.search(context.Background(), s, nums, []float64{target}, nil, &Stats{}, func(solution Solution) bool {
+		solutions = append(solutions, solution)
+		return true
+	})
+	sortSolutions(solutions)
+	return solutions, err
+}
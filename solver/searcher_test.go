@@ -0,0 +1,35 @@
+package solver_test
+
+import (
+	"testing"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// Both searches must find only solutions, and the subset search at least
+// one for every hand the default search solves.
+func TestSearchers(t *testing.T) {
+	slv := solver.New()
+	searchers := map[string]solver.Searcher{"default": slv, "subsets": slv.SubsetSearcher()}
+	for _, hand := range [][]float64{{3, 3, 8, 8}, {1, 5, 5, 5}, {1, 2, 3, 4}, {4, 4, 10, 10}, {1, 1, 1, 1}, {2, 12}, {1, 2, 3, 4, 5}} {
+		want, err := slv.FindAll(hand, 24)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, searcher := range searchers {
+			got, err := searcher.FindAll(hand, 24)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if (len(got) > 0) != (len(want) > 0) {
+				t.Errorf("%s: %v has %d solution(s), the default search finds %d", name, hand, len(got), len(want))
+			}
+			for _, solution := range got {
+				if value, err := solution.Tree.Eval(); err != nil || value < 24-1e-9 || value > 24+1e-9 {
+					t.Errorf("%s: %v: %s evaluates to %g, %v", name, hand, solution.Formula, value, err)
+				}
+			}
+			t.Logf("%s: %v: %d solution(s)", name, hand, len(got))
+		}
+	}
+}
@@ -0,0 +1,329 @@
+// Package solver finds every distinct way to combine a hand of numbers,
+// four in the classic game, with the basic arithmetic operators to reach a
+// target, 24 by default.
+package solver
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/x0root/24Solver/expr"
+)
+
+// Solution represents a single valid solution found.
+type Solution struct {
+	Formula string
+	Value   float64
+	// Tree is the expression tree the formula was rendered from. Every
+	// internal node carries the value of its sub-expression.
+	Tree *Node
+	// Key is the canonical key of Tree, see expr.CanonicalKey.
+	Key string
+	// Steps lists the operations of Tree in evaluation order,
+	// e.g. 8 / 3 = 2.667, 3 - 2.667 = 0.333, 8 / 0.333 = 24.
+	Steps []expr.Step
+	// Meta describes the operators and intermediate values involved.
+	Meta Metadata
+	// Variants lists every distinct formula found with the same canonical
+	// key, or merged as a sign-mirror, starting with Formula itself. Only
+	// Solve and SolveContext fill it, and only with WithVariants.
+	Variants []string
+}
+
+// sortSolutions puts solutions in a deterministic order that does not
+// depend on the order the search happened to find them in: those using
+// more numbers first, see WithSubsets, then by canonical key and formula.
+func sortSolutions(solutions []Solution) {
+	slices.SortFunc(solutions, func(a, b Solution) int {
+		if c := cmp.Compare(b.Meta.Numbers, a.Meta.Numbers); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Key, b.Key); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Formula, b.Formula)
+	})
+}
+
+// RootOperator returns the operator applied last when evaluating the
+// solution, i.e. the one at the root of its expression tree.
+func (s Solution) RootOperator() string {
+	return s.Tree.Op
+}
+
+// supported lists all the binary operators the solver knows, in display
+// order, and unaryOperators the unary ones, which have options of their
+// own. Which of them a search uses comes from the options of its Solver.
+var (
+	supported      = []string{"+", "-", "*", "/", "^", "%"}
+	unaryOperators = []string{"sqrt", "!", "neg"}
+)
+
+// DefaultOperators returns the operators of the classic game, + - * /,
+// which a Solver uses unless WithOperators says otherwise. The slice is
+// new on every call.
+func DefaultOperators() []string {
+	return []string{"+", "-", "*", "/"}
+}
+
+// defaultEpsilon is how close a float value must be to a whole number or
+// to the target to count as equal.
+const defaultEpsilon = 1e-9
+
+// Solver searches for solutions under a fixed configuration. Create one
+// with New; the zero value is not usable. A Solver is never modified after
+// New returns, so one Solver may be shared by any number of goroutines.
+type Solver struct {
+	target       float64
+	tolerance    float64
+	operators    []string
+	maxSolutions int
+	mergeMirrors bool
+	dedup        Dedup
+	filters      []Filter
+	variants     bool
+	workers      int
+	largeNumbers bool
+	negatives    bool
+	fractions    bool
+	decimalComma bool
+	sqrtDepth    int
+	factorialMax int
+	negation     bool
+	// concatenation allows joining adjacent dealt digits, see
+	// concatenations.
+	concatenation bool
+	// subsets lets solutions leave numbers out, see WithSubsets.
+	subsets bool
+	// maxUses is how often each number may be used, see WithReuse.
+	maxUses int
+	// required and forbidden are the operators of WithRequiredOperators
+	// and WithForbiddenOperators.
+	required  []string
+	forbidden []string
+	// integerDivision rejects fractional intermediate values, see
+	// WithIntegerDivision.
+	integerDivision bool
+	// nonNegative rejects negative intermediate values, see
+	// WithNonNegative.
+	nonNegative bool
+	// maxIntermediate caps the magnitude of intermediate values, see
+	// WithMaxIntermediate.
+	maxIntermediate float64
+	// subsetSearch runs the subset search on hands of any size, see
+	// SubsetSearcher.
+	subsetSearch bool
+	cache        *Cache
+	// progress is the function of WithProgress.
+	progress func(Progress)
+
+	// engine runs the search on the arithmetic backend chosen with
+	// WithArithmetic or WithEpsilon, or on exact fractions by default.
+	engine engine
+}
+
+// New returns a Solver for the classic game, making 24 with + - * /,
+// customized by opts.
+func New(opts ...Option) *Solver {
+	s := &Solver{
+		target:    24,
+		operators: DefaultOperators(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.forbid()
+	if s.engine == nil {
+		s.engine = typedEngine[Frac]{FracArithmetic{}}
+	}
+	return s
+}
+
+// forbid takes the operators of WithForbiddenOperators out of the search,
+// once every option has been applied.
+func (s *Solver) forbid() {
+	for _, op := range s.forbidden {
+		switch op {
+		case "sqrt":
+			s.sqrtDepth = 0
+		case "!":
+			s.factorialMax = 0
+		case "neg":
+			s.negation = false
+		default:
+			s.operators = slices.DeleteFunc(slices.Clone(s.operators), func(known string) bool {
+				return known == op
+			})
+		}
+	}
+}
+
+// Target returns the value solutions must reach.
+func (s *Solver) Target() float64 {
+	return s.target
+}
+
+// Tolerance returns how far from Target a solution may land, see
+// WithTolerance.
+func (s *Solver) Tolerance() float64 {
+	return s.tolerance
+}
+
+// Operators returns the operators the solver may use, in search order.
+func (s *Solver) Operators() []string {
+	return append([]string(nil), s.operators...)
+}
+
+// Solve finds all unique ways to make 24 from nums using + - * /.
+func Solve(nums []float64) ([]Solution, error) {
+	return New().Solve(nums)
+}
+
+// SolveContext is like Solve but stops early when ctx is done.
+func SolveContext(ctx context.Context, nums []float64) ([]Solution, error) {
+	return New().SolveContext(ctx, nums)
+}
+
+// Solve runs the full search over every permutation of nums and every
+// combination of the configured operators, returning the unique solutions
+// sorted by canonical key so the output is the same from run to run.
+func (s *Solver) Solve(nums []float64) ([]Solution, error) {
+	return s.SolveContext(context.Background(), nums)
+}
+
+// SolveContext is like Solve but checks ctx between permutation and
+// operator combinations. If ctx is done before the search finishes, it
+// returns the solutions found so far together with ctx.Err(); such partial
+// results are never cached.
+func (s *Solver) SolveContext(ctx context.Context, nums []float64) ([]Solution, error) {
+	return s.solve(ctx, nums, &Stats{})
+}
+
+// solve is SolveContext, adding the counts of the search to stats.
+func (s *Solver) solve(ctx context.Context, nums []float64, stats *Stats) ([]Solution, error) {
+	if err := s.validate(nums); err != nil {
+		return nil, err
+	}
+	key, cacheable := s.cacheKey(nums)
+	cacheable = cacheable && s.cache != nil
+	if cacheable {
+		if solutions, ok := s.cache.get(key); ok {
+			stats.Cached = true
+			return solutions, nil
+		}
+	}
+	var variants map[string][]string
+	if s.variants {
+		variants = make(map[string][]string)
+	}
+	var solutions []Solution
+	err := s.engine.search(ctx, s, nums, []float64{s.target}, variants, stats, func(solution Solution) bool {
+		solutions = append(solutions, solution)
+		return true
+	})
+	for i := range solutions {
+		solutions[i].Variants = variants[solutions[i].Key]
+	}
+	sortSolutions(solutions)
+	if cacheable && err == nil {
+		s.cache.put(key, solutions)
+	}
+	return solutions, err
+}
+
+// validate reports whether nums and the configured operators can be searched.
+func (s *Solver) validate(nums []float64) error {
+	if err := s.validateNumbers(nums); err != nil {
+		return err
+	}
+	if len(s.operators) == 0 {
+		return ErrNoOperators
+	}
+	for _, op := range s.operators {
+		if !isSupported(op) {
+			return fmt.Errorf("unsupported operator '%s'", op)
+		}
+	}
+	for _, op := range s.required {
+		if !s.allows(op) {
+			return fmt.Errorf("required operator '%s' is not allowed", op)
+		}
+	}
+	if s.tolerance > 0 && len(nums) > maxRangeNumbers {
+		return fmt.Errorf("a target range supports hands of at most %d numbers", maxRangeNumbers)
+	}
+	return nil
+}
+
+// search runs the search without input validation on the configured
+// arithmetic backend. See engine.search.
+func (s *Solver) search(ctx context.Context, nums []float64, yield func(Solution) bool) error {
+	return s.engine.search(ctx, s, nums, []float64{s.target}, nil, &Stats{}, yield)
+}
+
+// ParseForbiddenOperators turns an operator string like "*" or "sqrt!"
+// into the operators of WithForbiddenOperators. It takes the binary
+// operators ParseOperators does and the unary ones by name, sqrt, ! and
+// neg, optionally separated by spaces or commas, e.g. "^ % sqrt".
+func ParseForbiddenOperators(s string) ([]string, error) {
+	var ops []string
+	for rest := s; rest != ""; {
+		var op string
+		switch {
+		case rest[0] == ' ' || rest[0] == ',':
+			rest = rest[1:]
+			continue
+		case strings.HasPrefix(rest, "sqrt"):
+			op = "sqrt"
+		case strings.HasPrefix(rest, "neg"):
+			op = "neg"
+		default:
+			r, _ := utf8.DecodeRuneInString(rest)
+			op = string(r)
+			if !isSupported(op) && op != "!" {
+				return nil, fmt.Errorf("unsupported operator '%s'", op)
+			}
+		}
+		rest = rest[len(op):]
+		if !slices.Contains(ops, op) {
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
+// ParseOperators turns an operator string like "+-*" into an operator set
+// for WithOperators, rejecting anything the solver does not support.
+// Besides + - * /, the solver supports ^ for raising to a whole power and
+// % for the remainder of whole numbers. An operator given twice counts
+// once, so "+-" and "+-+" select the same set. An empty string selects
+// DefaultOperators.
+func ParseOperators(s string) ([]string, error) {
+	if s == "" {
+		return DefaultOperators(), nil
+	}
+	var opSet []string
+	for _, char := range s {
+		op := string(char)
+		if !isSupported(op) {
+			return nil, fmt.Errorf("unsupported operator '%s'", op)
+		}
+		if !slices.Contains(opSet, op) {
+			opSet = append(opSet, op)
+		}
+	}
+	return opSet, nil
+}
+
+func isSupported(op string) bool {
+	for _, known := range supported {
+		if op == known {
+			return true
+		}
+	}
+	return false
+}
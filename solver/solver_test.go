@@ -0,0 +1,69 @@
+package solver_test
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+func TestParseForbiddenOperators(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"*", []string{"*"}},
+		{"+-*/", []string{"+", "-", "*", "/"}},
+		{"sqrt", []string{"sqrt"}},
+		{"^ % sqrt", []string{"^", "%", "sqrt"}},
+		{"*,neg,!", []string{"*", "neg", "!"}},
+		{"sqrt!sqrt", []string{"sqrt", "!"}},
+	}
+	for _, tt := range tests {
+		got, err := solver.ParseForbiddenOperators(tt.input)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("ParseForbiddenOperators(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+	}
+	for _, input := range []string{"x", "sq", "*&"} {
+		if _, err := solver.ParseForbiddenOperators(input); err == nil {
+			t.Errorf("ParseForbiddenOperators(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestForbiddenUnaryOperators(t *testing.T) {
+	slv := solver.New(solver.WithSqrt(1), solver.WithFactorial(4), solver.WithForbiddenOperators("sqrt", "!"))
+	solutions, err := slv.Solve([]float64{1, 1, 1, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(solutions) != 0 {
+		t.Errorf("found %s with sqrt and ! forbidden, want no solution", solutions[0].Formula)
+	}
+}
+
+func TestSolverLookup(t *testing.T) {
+	for _, tt := range []struct {
+		opts     []solver.Option
+		nums     []float64
+		solvable bool
+		ok       bool
+	}{
+		{nil, []float64{8, 3, 8, 3}, true, true},
+		{nil, []float64{1, 1, 1, 1}, false, true},
+		{[]solver.Option{solver.WithOperators("/", "*", "-", "+"), solver.WithMaxSolutions(1)}, []float64{1, 1, 1, 1}, false, true},
+		{[]solver.Option{solver.WithArithmetic[*big.Rat](solver.RatArithmetic{})}, []float64{1, 1, 1, 1}, false, true},
+		{nil, []float64{1, 2, 3}, false, false},
+		{[]solver.Option{solver.WithSqrt(1)}, []float64{1, 1, 1, 1}, false, false},
+		{[]solver.Option{solver.WithTarget(4)}, []float64{1, 1, 1, 1}, false, false},
+		{[]solver.Option{solver.WithEpsilon(1e-9)}, []float64{1, 1, 1, 1}, false, false},
+		{[]solver.Option{solver.WithFilters(solver.NoDivision)}, []float64{3, 3, 8, 8}, false, false},
+	} {
+		_, solvable, ok := solver.New(tt.opts...).Lookup(tt.nums)
+		if solvable != tt.solvable || ok != tt.ok {
+			t.Errorf("Lookup(%v) with %d option(s) = %t, %t, want %t, %t", tt.nums, len(tt.opts), solvable, ok, tt.solvable, tt.ok)
+		}
+	}
+}
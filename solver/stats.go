@@ -0,0 +1,54 @@
+package solver
+
+import (
+	"context"
+	"time"
+)
+
+// Stats describes the work done by one solve, for judging how well the
+// search prunes.
+type Stats struct {
+	// Evaluated counts the operations computed, each one a candidate
+	// expression node.
+	Evaluated int64 `json:"evaluated"`
+	// Pruned counts candidates skipped without computing them because
+	// they would only repeat others, e.g. combining the second 8 of 8 8 3 3
+	// with a 3 after the first 8 has been.
+	Pruned int64 `json:"pruned"`
+	// DivByZero counts divisions skipped because the divisor was zero.
+	DivByZero int64 `json:"div_by_zero"`
+	// Duplicates counts expressions that reached the target but were
+	// collapsed into a solution already found.
+	Duplicates int64 `json:"duplicates"`
+	// Solutions is the number of unique solutions returned.
+	Solutions int `json:"solutions"`
+	// Cached reports whether the solutions came from WithCache without a
+	// search, in which case the counts are zero.
+	Cached bool `json:"cached,omitempty"`
+	// Elapsed is the wall time of the solve.
+	Elapsed time.Duration `json:"elapsed_ns"`
+}
+
+// counters is the part of Stats one worker counts on its own, so the
+// search never has to synchronize to count.
+type counters struct {
+	evaluated, pruned, divByZero int64
+}
+
+// addTo adds the counts to stats.
+func (c *counters) addTo(stats *Stats) {
+	stats.Evaluated += c.evaluated
+	stats.Pruned += c.pruned
+	stats.DivByZero += c.divByZero
+}
+
+// SolveStats is like SolveContext but also returns statistics about the
+// search.
+func (s *Solver) SolveStats(ctx context.Context, nums []float64) ([]Solution, Stats, error) {
+	var stats Stats
+	start := time.Now()
+	solutions, err := s.solve(ctx, nums, &stats)
+	stats.Solutions = len(solutions)
+	stats.Elapsed = time.Since(start)
+	return solutions, stats, err
+}
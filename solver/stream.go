@@ -0,0 +1,35 @@
+package solver
+
+import "context"
+
+// SolveStream is like Solve but sends each unique solution on the returned
+// channel as soon as it is found. The channel is closed when the search
+// ends; it is closed immediately if nums is not a valid hand. The caller
+// must drain the channel, or use Solver.SolveStream with a context to stop
+// early.
+func SolveStream(nums []float64) <-chan Solution {
+	return New().SolveStream(context.Background(), nums)
+}
+
+// SolveStream sends each unique solution on the returned channel as soon
+// as it is found, in discovery order rather than the sorted order of
+// Solve, and closes the channel when the search ends. Cancelling
+// ctx stops the search and closes the channel without sending the rest.
+func (s *Solver) SolveStream(ctx context.Context, nums []float64) <-chan Solution {
+	ch := make(chan Solution)
+	go func() {
+		defer close(ch)
+		if s.validate(nums) != nil {
+			return
+		}
+		s.search(ctx, nums, func(solution Solution) bool {
+			select {
+			case ch <- solution:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
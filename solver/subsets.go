@@ -0,0 +1,600 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+const (
+	// subsetSearchMin is the hand size from which search switches from
+	// trying every expression tree to the subset search.
+	subsetSearchMin = 5
+	// storedSubsetMax is the largest subset whose reachable values are all
+	// computed and kept. Larger subsets are only asked for specific values.
+	storedSubsetMax = 5
+	// maxRangeNumbers is the largest hand that can be searched for a
+	// target range: both parts of every split of the hand are stored.
+	maxRangeNumbers = storedSubsetMax + 1
+)
+
+// errFound ends a goal search at its first witness.
+var errFound = errors.New("goal reached")
+
+// reachable is the set of distinct values one subset of the hand can make,
+// each with a single witness expression, in the order they were found.
+type reachable[T any] struct {
+	terms []term[T]
+	index map[float64]int
+}
+
+// add records t unless its value has already been reached.
+func (r *reachable[T]) add(ar Arithmetic[T], t term[T]) {
+	key := valueKey(ar.Float(t.value))
+	if _, ok := r.index[key]; ok {
+		return
+	}
+	r.index[key] = len(r.terms)
+	r.terms = append(r.terms, t)
+}
+
+// addOp records a op b, which has the given value, unless the value has
+// already been reached. The node is only allocated for new values.
+func (r *reachable[T]) addOp(ar Arithmetic[T], value T, op string, a, b term[T]) {
+	f := ar.Float(value)
+	key := valueKey(f)
+	if _, ok := r.index[key]; ok {
+		return
+	}
+	r.index[key] = len(r.terms)
+	r.terms = append(r.terms, term[T]{value: value, node: &Node{Op: op, Value: f, Left: a.node, Right: b.node}})
+}
+
+// valueKey rounds a value so that results differing only by floating point
+// error share a key.
+func valueKey(v float64) float64 {
+	return math.Round(v*1e9) / 1e9
+}
+
+// witness is the memoized answer to whether a subset can reach a value.
+type witness[T any] struct {
+	term term[T]
+	ok   bool
+}
+
+// subsetSearch is a dynamic program over the subsets of the hand, named by
+// bit masks of the positions they contain. It keeps a single expression
+// per reachable value, which is what makes hands of 7 or 8 numbers
+// tractable. Each worker has its own subsetSearch; values is shared and
+// only read once the search starts.
+type subsetSearch[T any] struct {
+	ctx   context.Context
+	st    *searchState[T]
+	terms []term[T]
+	zero  T
+	// sameAs is the result of sameNumbers for terms.
+	sameAs []int
+	// values holds the reachable values of every subset of up to
+	// storedSubsetMax numbers.
+	values []*reachable[T]
+	// goals memoizes, for larger subsets, whether they can reach a value.
+	goals map[int]map[float64]witness[T]
+	// nodes has one reused node for each subset size, see eachOf.
+	nodes []Node
+	// counts is what the worker counted.
+	counts counters
+}
+
+// split is one way of dividing a subset into two parts. small never has
+// more numbers than large.
+type split struct {
+	small, large int
+}
+
+// splits lists every split of mask once.
+func splits(mask int) []split {
+	var result []split
+	for sub := (mask - 1) & mask; sub > 0; sub = (sub - 1) & mask {
+		small, large := sub, mask^sub
+		n, m := bits.OnesCount(uint(small)), bits.OnesCount(uint(large))
+		// Equal halves would otherwise be listed twice.
+		if n > m || n == m && small > large {
+			continue
+		}
+		result = append(result, split{small, large})
+	}
+	return result
+}
+
+// subsets reports every expression that reaches the target by splitting
+// the whole hand in two and combining a value of one part with a value of
+// the other. Different solutions can therefore still appear, but only one
+// expression per value of each part. The stored subsets are filled in
+// size by size across the workers, then each split of the hand is one
+// task. Expressions under a unary chain are found by looking for the value
+// the chain turns into the target, see preimages.
+func (st *searchState[T]) subsets(terms []term[T]) error {
+	full := 1<<len(terms) - 1
+	workers := workerCount(st.s.workers)
+	sameAs := sameNumbers(terms)
+	values, err := st.fill(terms, sameAs)
+	if err != nil {
+		return err
+	}
+
+	// Splits into parts holding the same numbers as an earlier split
+	// would only repeat its solutions.
+	var top []split
+	seen := make(map[split]bool)
+	for _, sp := range splits(full) {
+		same := split{sameAs[sp.small], sameAs[sp.large]}
+		if !seen[same] {
+			seen[same] = true
+			top = append(top, sp)
+		} else {
+			st.counts.pruned++
+		}
+	}
+	pres := make([][]preimage[T], len(st.goals))
+	for i, goal := range st.goals {
+		pres[i] = st.preimages(goal)
+	}
+	searches := make([]*subsetSearch[T], workers)
+	defer func() {
+		for _, d := range searches {
+			if d != nil {
+				d.counts.addTo(st.stats)
+			}
+		}
+	}()
+	return inOrder(st.ctx, len(top), workers, func(ctx context.Context, worker, task int, emit func(*Node)) error {
+		d := searches[worker]
+		if d == nil {
+			d = &subsetSearch[T]{ctx: ctx, st: st, terms: terms, zero: st.zero, sameAs: sameAs, values: values, goals: make(map[int]map[float64]witness[T]), nodes: make([]Node, len(terms)+1)}
+			searches[worker] = d
+		}
+		hit := func(t term[T]) error {
+			if st.hits(t.value) {
+				emit(t.node.Clone())
+			}
+			return nil
+		}
+		if st.ranged {
+			// A range has no single value to work back from.
+			return d.eachPairOf(top[task], func(t term[T]) error {
+				if err := hit(t); err != nil {
+					return err
+				}
+				return st.eachUnary(t, &d.counts, hit)
+			})
+		}
+		for i, goal := range st.goals {
+			if err := d.eachOf(top[task], goal, hit); err != nil {
+				return err
+			}
+			for _, pre := range pres[i] {
+				err := d.eachOf(top[task], pre.value, func(t term[T]) error {
+					if w, ok := st.wrap(t, pre, &d.counts); ok {
+						return hit(w)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}, st.report, st.taskDone(len(top)))
+}
+
+// fill computes the reachable values of every subset of terms, other than
+// the whole hand, of up to storedSubsetMax numbers, size by size across
+// the workers. Subsets holding the same numbers, see sameNumbers, share
+// their values.
+func (st *searchState[T]) fill(terms []term[T], sameAs []int) ([]*reachable[T], error) {
+	full := 1<<len(terms) - 1
+	workers := workerCount(st.s.workers)
+	values := make([]*reachable[T], full+1)
+	for size := 1; size <= min(storedSubsetMax, len(terms)-1); size++ {
+		if err := st.ctx.Err(); err != nil {
+			return nil, err
+		}
+		var masks []int
+		for mask := 1; mask < full; mask++ {
+			if bits.OnesCount(uint(mask)) != size {
+				continue
+			}
+			if sameAs[mask] == mask {
+				masks = append(masks, mask)
+			} else {
+				// Filled in from the representative below.
+				st.counts.pruned++
+			}
+		}
+		counts := make([]counters, len(masks))
+		forEach(len(masks), workers, func(i int) {
+			values[masks[i]] = st.reachableFrom(terms, values, masks[i], &counts[i])
+		})
+		for i := range counts {
+			counts[i].addTo(st.stats)
+		}
+		for mask := 1; mask < full; mask++ {
+			if bits.OnesCount(uint(mask)) == size {
+				values[mask] = values[sameAs[mask]]
+			}
+		}
+	}
+	return values, nil
+}
+
+// sameNumbers maps every subset of terms to the first subset, in mask
+// order, that holds the same numbers. With repeated numbers, e.g. 8 8 3 3
+// 3, subsets such as the first 8 with the first 3 and the second 8 with
+// the third 3 can reach the same values, so the work is done only once.
+func sameNumbers[T any](terms []term[T]) []int {
+	full := 1<<len(terms) - 1
+	sameAs := make([]int, full+1)
+	first := make(map[string]int)
+	for mask := 1; mask <= full; mask++ {
+		var nums []float64
+		for i, t := range terms {
+			if mask&(1<<i) != 0 {
+				nums = append(nums, t.node.Value)
+			}
+		}
+		slices.Sort(nums)
+		key := fmt.Sprint(nums)
+		if _, ok := first[key]; !ok {
+			first[key] = mask
+		}
+		sameAs[mask] = first[key]
+	}
+	return sameAs
+}
+
+// each passes to fn every expression over mask that should make goal,
+// trying each split of mask in turn.
+func (d *subsetSearch[T]) each(mask int, goal T, fn func(term[T]) error) error {
+	for _, sp := range splits(mask) {
+		if err := d.eachOf(sp, goal, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eachOf passes to fn every expression over one split that should make
+// goal: for each value of the smaller part, each operator and each order,
+// it works out the value the larger part must make and looks it up. fn
+// must still check the value, which can differ from goal by rounding, and
+// clone the node of any term it keeps: the node is reused for the next
+// candidate.
+func (d *subsetSearch[T]) eachOf(sp split, goal T, fn func(term[T]) error) error {
+	st := d.st
+	node := &d.nodes[bits.OnesCount(uint(sp.small|sp.large))]
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+	for _, p := range d.values[sp.small].terms {
+		for _, op := range st.s.operators {
+			for _, pLeft := range [2]bool{true, false} {
+				if !pLeft && st.mirrorsOnly(op) {
+					d.counts.pruned++
+					break
+				}
+				q, ok := d.partner(sp.large, op, p.value, goal, pLeft)
+				if !ok {
+					continue
+				}
+				a, b := p, q
+				if !pLeft {
+					a, b = q, p
+				}
+				value, ok := st.calculate(&d.counts, a.value, b.value, op)
+				if !ok {
+					continue
+				}
+				*node = Node{Op: op, Value: st.ar.Float(value), Left: a.node, Right: b.node}
+				if err := fn(term[T]{value: value, node: node}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// eachPairOf is like eachOf without a goal: it passes to fn every
+// combination of a value of one part with a value of the other, so both
+// parts must be stored. It is used for target ranges.
+func (d *subsetSearch[T]) eachPairOf(sp split, fn func(term[T]) error) error {
+	st := d.st
+	node := &d.nodes[bits.OnesCount(uint(sp.small|sp.large))]
+	for _, p := range d.values[sp.small].terms {
+		if err := d.ctx.Err(); err != nil {
+			return err
+		}
+		for _, q := range d.values[sp.large].terms {
+			for _, op := range st.s.operators {
+				for k, pair := range [2][2]term[T]{{p, q}, {q, p}} {
+					if k == 1 && st.mirrorsOnly(op) {
+						d.counts.pruned++
+						break
+					}
+					a, b := pair[0], pair[1]
+					value, ok := st.calculate(&d.counts, a.value, b.value, op)
+					if !ok {
+						continue
+					}
+					*node = Node{Op: op, Value: st.ar.Float(value), Left: a.node, Right: b.node}
+					if err := fn(term[T]{value: value, node: node}); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// partner finds an expression q over mask such that p op q (or q op p when
+// pLeft is false) makes goal, by inverting op.
+func (d *subsetSearch[T]) partner(mask int, op string, p, goal T, pLeft bool) (term[T], bool) {
+	ar := d.st.ar
+	var want T
+	ok := false
+	switch {
+	case op == "+":
+		want, ok = ar.Sub(goal, p)
+	case op == "-" && pLeft:
+		want, ok = ar.Sub(p, goal)
+	case op == "-":
+		want, ok = ar.Add(goal, p)
+	case op == "*":
+		if ar.Equal(p, d.zero) {
+			// 0 * q is 0 whatever q is.
+			if ar.Equal(goal, d.zero) {
+				return d.anyOf(mask), true
+			}
+			return term[T]{}, false
+		}
+		want, ok = ar.Div(goal, p)
+	case op == "/" && pLeft:
+		if ar.Equal(goal, d.zero) {
+			// 0 / q is 0 for any q, which calculate checks is not 0.
+			if ar.Equal(p, d.zero) {
+				return d.anyOf(mask), true
+			}
+			return term[T]{}, false
+		}
+		want, ok = ar.Div(p, goal)
+	case op == "/":
+		want, ok = ar.Mul(goal, p)
+	case op == "^":
+		return d.powerPartner(mask, p, goal, pLeft)
+	case op == "%":
+		return d.remainderPartner(mask, p, goal, pLeft)
+	default:
+		return term[T]{}, false
+	}
+	if !ok {
+		return term[T]{}, false
+	}
+	return d.reach(mask, want)
+}
+
+// powerPartner is partner for ^. There is no exact inverse, so it works
+// out the few candidates in floats and checks each with power: the
+// exponent q of p^q = goal is a whole number, and the base q of q^p = goal
+// is a root of goal, or either root when p is even.
+func (d *subsetSearch[T]) powerPartner(mask int, p, goal T, pLeft bool) (term[T], bool) {
+	ar := d.st.ar
+	base, g := ar.Float(p), ar.Float(goal)
+	var candidates []float64
+	switch {
+	case pLeft && (base == 0 || math.Abs(base) == 1):
+		// Many exponents give the same power of 0, 1 and -1.
+		for e := -maxExponent; e <= maxExponent; e++ {
+			candidates = append(candidates, float64(e))
+		}
+	case pLeft:
+		if g == 0 {
+			return term[T]{}, false
+		}
+		candidates = append(candidates, math.Round(math.Log(math.Abs(g))/math.Log(math.Abs(base))))
+	case base == 0:
+		// q^0 is 1 for any q but 0.
+		if ar.Equal(goal, d.zero) {
+			return term[T]{}, false
+		}
+		if t := d.anyOf(mask); !ar.Equal(t.value, d.zero) {
+			q, _ := power(ar, t.value, p)
+			if ar.Equal(q, goal) {
+				return t, true
+			}
+		}
+		return term[T]{}, false
+	default:
+		root := math.Pow(math.Abs(g), 1/base)
+		candidates = append(candidates, root, -root)
+	}
+	for _, c := range candidates {
+		want, ok := ar.FromFloat(c)
+		if !ok {
+			continue
+		}
+		a, b := p, want
+		if !pLeft {
+			a, b = want, p
+		}
+		if value, ok := power(ar, a, b); !ok || !ar.Equal(value, goal) {
+			continue
+		}
+		if t, ok := d.reach(mask, want); ok {
+			return t, true
+		}
+	}
+	return term[T]{}, false
+}
+
+// remainderPartner is partner for %, which has no inverse either: q % p =
+// goal holds for every q that is goal plus a multiple of p, and p % q =
+// goal for every q larger than goal when p is goal. A stored mask is
+// scanned for a value that works. Otherwise only p % q is tried, for the q
+// dividing p - goal, up to maxDivided, so a remainder over more than
+// storedSubsetMax numbers can go unfound.
+func (d *subsetSearch[T]) remainderPartner(mask int, p, goal T, pLeft bool) (term[T], bool) {
+	ar := d.st.ar
+	if bits.OnesCount(uint(mask)) <= storedSubsetMax {
+		for _, q := range d.values[mask].terms {
+			a, b := p, q.value
+			if !pLeft {
+				a, b = q.value, p
+			}
+			if value, ok := remainder(ar, a, b); ok && ar.Equal(value, goal) {
+				return q, true
+			}
+		}
+		return term[T]{}, false
+	}
+	x, g := ar.Float(p), ar.Float(goal)
+	n := math.Abs(x - g)
+	if !pLeft || n == 0 || n > maxDivided || n != math.Trunc(n) {
+		return term[T]{}, false
+	}
+	for k := 1.0; k*k <= n; k++ {
+		if math.Mod(n, k) != 0 {
+			continue
+		}
+		for _, c := range [4]float64{k, -k, n / k, -n / k} {
+			want, ok := ar.FromFloat(c)
+			if !ok {
+				continue
+			}
+			if value, ok := remainder(ar, p, want); !ok || !ar.Equal(value, goal) {
+				continue
+			}
+			if t, ok := d.reach(mask, want); ok {
+				return t, true
+			}
+		}
+	}
+	return term[T]{}, false
+}
+
+// maxDivided bounds the numbers remainderPartner looks for divisors of.
+const maxDivided = 1e8
+
+// reach returns an expression over mask whose value is goal, if there is
+// one.
+func (d *subsetSearch[T]) reach(mask int, goal T) (term[T], bool) {
+	ar := d.st.ar
+	key := valueKey(ar.Float(goal))
+	if bits.OnesCount(uint(mask)) <= storedSubsetMax {
+		r := d.values[mask]
+		if i, ok := r.index[key]; ok && ar.Equal(r.terms[i].value, goal) {
+			return r.terms[i], true
+		}
+		return term[T]{}, false
+	}
+
+	memo := d.goals[d.sameAs[mask]]
+	if memo == nil {
+		memo = make(map[float64]witness[T])
+		d.goals[d.sameAs[mask]] = memo
+	}
+	if w, ok := memo[key]; ok {
+		return w.term, w.ok
+	}
+	var w witness[T]
+	d.each(mask, goal, func(t term[T]) error {
+		if !ar.Equal(t.value, goal) {
+			return nil
+		}
+		w = witness[T]{term: term[T]{value: t.value, node: shallowCopy(t.node)}, ok: true}
+		return errFound
+	})
+	for _, pre := range d.st.preimages(goal) {
+		if w.ok {
+			break
+		}
+		d.each(mask, pre.value, func(t term[T]) error {
+			if !ar.Equal(t.value, pre.value) {
+				return nil
+			}
+			u, ok := d.st.wrap(term[T]{value: t.value, node: shallowCopy(t.node)}, pre, &d.counts)
+			if !ok || !ar.Equal(u.value, goal) {
+				return nil
+			}
+			w = witness[T]{term: u, ok: true}
+			return errFound
+		})
+	}
+	memo[key] = w
+	return w.term, w.ok
+}
+
+// shallowCopy returns a copy of the root of a tree whose children are
+// already kept elsewhere.
+func shallowCopy(n *Node) *Node {
+	c := *n
+	return &c
+}
+
+// anyOf returns some expression over mask, for the cases where its value
+// does not matter: the sum of its numbers.
+func (d *subsetSearch[T]) anyOf(mask int) term[T] {
+	var t term[T]
+	for i, next := range d.terms {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		if t.node == nil {
+			t = next
+			continue
+		}
+		value, _ := d.st.ar.Add(t.value, next.value)
+		t = term[T]{value: value, node: &Node{Op: "+", Value: d.st.ar.Float(value), Left: t.node, Right: next.node}}
+	}
+	return t
+}
+
+// reachableFrom computes every value mask can reach from the values of
+// its parts, which must already be in values, counting its work in c.
+// With unary operators, the unary forms of the values count as well.
+func (st *searchState[T]) reachableFrom(terms []term[T], values []*reachable[T], mask int, c *counters) *reachable[T] {
+	r := &reachable[T]{index: make(map[float64]int)}
+	if bits.OnesCount(uint(mask)) == 1 {
+		r.add(st.ar, terms[bits.TrailingZeros(uint(mask))])
+		st.addUnary(r, c)
+		return r
+	}
+	for _, sp := range splits(mask) {
+		for _, x := range values[sp.small].terms {
+			for _, y := range values[sp.large].terms {
+				for _, op := range st.s.operators {
+					for k, pair := range [2][2]term[T]{{x, y}, {y, x}} {
+						// Only the first expression of each value is
+						// kept, so the mirror image never would be.
+						if k == 1 && commutative(op) {
+							c.pruned++
+							break
+						}
+						a, b := pair[0], pair[1]
+						value, ok := st.calculate(c, a.value, b.value, op)
+						if !ok {
+							continue
+						}
+						r.addOp(st.ar, value, op, a, b)
+					}
+				}
+			}
+		}
+	}
+	st.addUnary(r, c)
+	return r
+}
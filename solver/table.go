@@ -0,0 +1,132 @@
+package solver
+
+import (
+	_ "embed"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:generate go run ./internal/gentable
+
+// handsTable is the output of internal/gentable: one line per standard
+// hand, four card values from 1 to 13 in ascending order, followed by a
+// solution or "-".
+//
+//go:embed hands.txt
+var handsTable string
+
+// standardHand is a hand of four card values in ascending order.
+type standardHand [4]int
+
+// standardHands parses handsTable the first time it is needed.
+var standardHands = sync.OnceValue(func() map[standardHand]string {
+	table := make(map[standardHand]string, 1820)
+	for _, line := range strings.Split(handsTable, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 5)
+		var hand standardHand
+		for i := range hand {
+			hand[i], _ = strconv.Atoi(fields[i])
+		}
+		table[hand] = fields[4]
+	}
+	return table
+})
+
+// StandardHands returns the 1820 standard hands of the classic game, every
+// multiset of four card values from 1 to 13, each in ascending order and
+// listed in ascending order.
+func StandardHands() [][]float64 {
+	var hands [][]float64
+	for a := 1; a <= 13; a++ {
+		for b := a; b <= 13; b++ {
+			for c := b; c <= 13; c++ {
+				for d := c; d <= 13; d++ {
+					hands = append(hands, []float64{float64(a), float64(b), float64(c), float64(d)})
+				}
+			}
+		}
+	}
+	return hands
+}
+
+// toStandard returns nums as a standardHand if it is one.
+func toStandard(nums []float64) (standardHand, bool) {
+	var hand standardHand
+	if len(nums) != len(hand) {
+		return hand, false
+	}
+	for i, num := range nums {
+		if checkRange(num, 1, 13) != nil {
+			return hand, false
+		}
+		hand[i] = int(num)
+	}
+	slices.Sort(hand[:])
+	return hand, true
+}
+
+// Lookup answers a standard hand, four card values from 1 to 13 in any
+// order, from a table computed ahead of time for the classic game of
+// making 24 with + - * /. It reports whether the hand is solvable and, if
+// so, one solution, as the default Solver's First would find it for the
+// numbers in ascending order. ok is false for any other hand.
+func Lookup(nums []float64) (formula string, solvable, ok bool) {
+	hand, ok := toStandard(nums)
+	if !ok {
+		return "", false, false
+	}
+	formula = standardHands()[hand]
+	if formula == "-" {
+		return "", false, true
+	}
+	return formula, true, true
+}
+
+// Lookup is the package Lookup for the configuration of s: it answers a
+// standard hand from the table only when s plays the classic game the
+// table was computed for, making exactly 24 with + - * / and every number
+// once, with nothing else that changes which hands are solvable. Otherwise
+// ok is false and the hand has to be searched.
+func (s *Solver) Lookup(nums []float64) (formula string, solvable, ok bool) {
+	if !s.classic() {
+		return "", false, false
+	}
+	return Lookup(nums)
+}
+
+// classic reports whether s finds a solution for exactly the hands the
+// default Solver does. Options that only pick, order or count the
+// solutions, or that widen the accepted numbers, do not matter.
+func (s *Solver) classic() bool {
+	switch s.engine.(type) {
+	case typedEngine[Frac], typedEngine[*big.Rat]:
+	default:
+		return false
+	}
+	ops := slices.Clone(s.operators)
+	slices.Sort(ops)
+	defaults := DefaultOperators()
+	slices.Sort(defaults)
+	return s.target == 24 && s.tolerance == 0 && slices.Equal(ops, defaults) &&
+		s.sqrtDepth == 0 && s.factorialMax == 0 && !s.negation &&
+		!s.concatenation && !s.subsets && s.maxUses < 2 &&
+		len(s.required) == 0 && len(s.filters) == 0 &&
+		!s.integerDivision && !s.nonNegative && s.maxIntermediate == 0
+}
+
+// Solvable reports whether nums can make 24 with + - * /. The 1820
+// standard hands are answered from the table behind Lookup without
+// searching; any other hand is searched.
+func Solvable(nums []float64) (bool, error) {
+	if _, solvable, ok := Lookup(nums); ok {
+		return solvable, nil
+	}
+	_, solvable, err := New().First(nums)
+	return solvable, err
+}
@@ -0,0 +1,53 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// SolveTargets is like Solve for each of targets, instead of the target
+// the Solver was created with, but finds the solutions of all of them in
+// a single search, which costs little more than searching for one. The
+// result maps every target to its solutions, sorted as Solve sorts them;
+// a target that cannot be reached maps to none. WithMaxSolutions limits
+// the solutions of each target. Results for several targets are not
+// cached, and a target range cannot be combined with more than one target.
+func (s *Solver) SolveTargets(nums []float64, targets []float64) (map[float64][]Solution, error) {
+	return s.SolveTargetsContext(context.Background(), nums, targets)
+}
+
+// SolveTargetsContext is like SolveTargets but stops early when ctx is
+// done, returning the solutions found so far together with ctx.Err().
+func (s *Solver) SolveTargetsContext(ctx context.Context, nums []float64, targets []float64) (map[float64][]Solution, error) {
+	if err := s.validate(nums); err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no targets to solve for")
+	}
+	if s.tolerance > 0 && len(targets) > 1 {
+		return nil, errors.New("a target range cannot be combined with several targets")
+	}
+	targets = slices.Compact(slices.Sorted(slices.Values(targets)))
+	var variants map[string][]string
+	if s.variants {
+		variants = make(map[string][]string)
+	}
+	results := make(map[float64][]Solution, len(targets))
+	for _, target := range targets {
+		results[target] = nil
+	}
+	err := s.engine.search(ctx, s, nums, targets, variants, &Stats{}, func(solution Solution) bool {
+		target := targets[targetOf(solution.Value, targets)]
+		results[target] = append(results[target], solution)
+		return true
+	})
+	for _, solutions := range results {
+		for i := range solutions {
+			solutions[i].Variants = variants[solutions[i].Key]
+		}
+		sortSolutions(solutions)
+	}
+	return results, err
+}
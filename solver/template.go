@@ -0,0 +1,76 @@
+package solver
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/x0root/24Solver/expr"
+)
+
+// parseTemplate parses a template into a tree skeleton and returns its
+// leaves in the order they appear, so they can be filled with digits.
+func parseTemplate(input string) (*Node, []*Node, error) {
+	root, leaves, err := expr.ParseTemplate(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(leaves) != 4 {
+		return nil, nil, fmt.Errorf("template must have exactly 4 placeholders, found %d", len(leaves))
+	}
+	return root, leaves, nil
+}
+
+// TemplateHand is a hand that fits a template, together with one
+// assignment of its numbers to the placeholders that reaches the target.
+type TemplateHand struct {
+	Numbers []float64 // sorted ascending
+	Formula string
+}
+
+// SolveTemplate returns every hand of digits 1-9 that reaches target when
+// its digits are assigned, in some order, to the placeholders of template,
+// e.g. "(a+b)*(c+d)". Letters are placeholders and must each appear once.
+// Hands are returned in ascending order.
+func SolveTemplate(template string, target float64) ([]TemplateHand, error) {
+	root, leaves, err := parseTemplate(template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	seenHands := make(map[string]bool)
+	var hands []TemplateHand
+	digits := make([]float64, len(leaves))
+	var assign func(i int)
+	assign = func(i int) {
+		if i == len(leaves) {
+			if !reaches(FracArithmetic{}, root, target) {
+				return
+			}
+			sorted := append([]float64(nil), digits...)
+			sort.Float64s(sorted)
+			key := fmt.Sprint(sorted)
+			if !seenHands[key] {
+				seenHands[key] = true
+				hands = append(hands, TemplateHand{Numbers: sorted, Formula: root.MinimalInfix()})
+			}
+			return
+		}
+		for d := 1.0; d <= 9; d++ {
+			digits[i] = d
+			leaves[i].Value = d
+			assign(i + 1)
+		}
+	}
+	assign(0)
+
+	sort.Slice(hands, func(i, j int) bool {
+		a, b := hands[i].Numbers, hands[j].Numbers
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+	return hands, nil
+}
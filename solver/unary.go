@@ -0,0 +1,223 @@
+package solver
+
+import (
+	"math"
+	"slices"
+)
+
+// maxFactorial is the largest number WithFactorial allows the factorial
+// of: 20! is the largest factorial that fits in an int64.
+const maxFactorial = 20
+
+// calculateUnary applies the unary operator op to x using the arithmetic
+// backend ar.
+func calculateUnary[T any](ar Arithmetic[T], x T, op string) (T, bool) {
+	switch op {
+	case "sqrt":
+		return squareRoot(ar, x)
+	case "!":
+		return factorial(ar, x)
+	case "neg":
+		zero, _ := ar.FromFloat(0)
+		return ar.Sub(zero, x)
+	}
+	var zero T
+	return zero, false
+}
+
+// squareRoot finds the square root of x in floats and checks it by
+// squaring, so it reports false for roots the backend cannot represent,
+// such as the root of 2 on exact backends.
+func squareRoot[T any](ar Arithmetic[T], x T) (T, bool) {
+	var zero T
+	f := ar.Float(x)
+	if f < 0 {
+		return zero, false
+	}
+	root, ok := ar.FromFloat(math.Sqrt(f))
+	if !ok {
+		return zero, false
+	}
+	square, ok := ar.Mul(root, root)
+	if !ok || !ar.Equal(square, x) {
+		return zero, false
+	}
+	return root, true
+}
+
+// factorial computes x! for a whole x from 0 to maxFactorial by repeated
+// multiplication, and reports false for any other x.
+func factorial[T any](ar Arithmetic[T], x T) (T, bool) {
+	var zero T
+	n := ar.Float(x)
+	if n != math.Trunc(n) || n < 0 || n > maxFactorial {
+		return zero, false
+	}
+	result, ok := ar.FromFloat(1)
+	for k := 2; ok && k <= int(n); k++ {
+		var factor T
+		if factor, ok = ar.FromFloat(float64(k)); ok {
+			result, ok = ar.Mul(result, factor)
+		}
+	}
+	return result, ok
+}
+
+// hasUnary reports whether the search may apply unary operators.
+func (s *Solver) hasUnary() bool {
+	return s.sqrtDepth > 0 || s.factorialMax > 0 || s.negation
+}
+
+// allows reports whether s may use op, binary or unary.
+func (s *Solver) allows(op string) bool {
+	switch op {
+	case "sqrt":
+		return s.sqrtDepth > 0
+	case "!":
+		return s.factorialMax > 0
+	case "neg":
+		return s.negation
+	}
+	return slices.Contains(s.operators, op)
+}
+
+// eachUnary passes to fn every other form of t that applying a chain of
+// unary operators to it makes, e.g. sqrt(9) and sqrt(sqrt(9)) of 9 with
+// WithSqrt(2), or 3! and (3!)! with WithFactorial(6). Forms with the same
+// value as the term they apply to, such as sqrt(1) or 2!, are left out, and
+// so is negating a negation. The nodes are new, so fn may keep them.
+func (st *searchState[T]) eachUnary(t term[T], c *counters, fn func(term[T]) error) error {
+	return st.unaryChain(t, st.s.sqrtDepth, "", c, fn)
+}
+
+// unaryChain is eachUnary with at most sqrts more square roots, after the
+// unary operator last, if any.
+func (st *searchState[T]) unaryChain(t term[T], sqrts int, last string, c *counters, fn func(term[T]) error) error {
+	for _, op := range unaryOperators {
+		left := sqrts
+		switch op {
+		case "sqrt":
+			if sqrts == 0 {
+				continue
+			}
+			left--
+		case "!":
+			if st.s.factorialMax == 0 || st.ar.Float(t.value) > float64(st.s.factorialMax) {
+				continue
+			}
+		case "neg":
+			if !st.s.negation || last == "neg" {
+				continue
+			}
+		}
+		c.evaluated++
+		value, ok := calculateUnary(st.ar, t.value, op)
+		if !ok || st.ar.Equal(value, t.value) || !st.admits(value) {
+			continue
+		}
+		u := term[T]{value: value, node: &Node{Op: op, Value: st.ar.Float(value), Left: t.node}}
+		if err := fn(u); err != nil {
+			return err
+		}
+		if err := st.unaryChain(u, left, op, c, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preimage is a value from which a chain of unary operators, ops with the
+// outermost first, makes a goal.
+type preimage[T any] struct {
+	value T
+	ops   []string
+}
+
+// preimages returns the values that unary chains turn into goal, so a
+// goal search can look for them too: e.g. 576 and 331776 for 24 with
+// WithSqrt(2), 4 with WithFactorial(4), or -24 with WithNegation.
+func (st *searchState[T]) preimages(goal T) []preimage[T] {
+	type factorialOf struct{ n, value T }
+	var factorials []factorialOf
+	for k := range st.s.factorialMax + 1 {
+		n, _ := st.ar.FromFloat(float64(k))
+		if f, ok := factorial(st.ar, n); ok && !st.ar.Equal(f, n) {
+			factorials = append(factorials, factorialOf{n, f})
+		}
+	}
+	var result []preimage[T]
+	var from func(value T, ops []string, sqrts int)
+	add := func(value T, ops []string, op string, sqrts int) {
+		ops = append(slices.Clone(ops), op)
+		result = append(result, preimage[T]{value: value, ops: ops})
+		from(value, ops, sqrts)
+	}
+	from = func(value T, ops []string, sqrts int) {
+		if sqrts > 0 && st.ar.Float(value) >= 0 {
+			if square, ok := st.ar.Mul(value, value); ok && !st.ar.Equal(square, value) {
+				add(square, ops, "sqrt", sqrts-1)
+			}
+		}
+		for _, f := range factorials {
+			if st.ar.Equal(f.value, value) {
+				add(f.n, ops, "!", sqrts)
+			}
+		}
+		if st.s.negation && (len(ops) == 0 || ops[len(ops)-1] != "neg") && !st.ar.Equal(value, st.zero) {
+			if negated, ok := calculateUnary(st.ar, value, "neg"); ok {
+				add(negated, ops, "neg", sqrts)
+			}
+		}
+	}
+	from(goal, nil, st.s.sqrtDepth)
+	return result
+}
+
+// wrap applies the unary chain of pre to t, reporting false if one of the
+// operations turns out undefined. The nodes are new.
+func (st *searchState[T]) wrap(t term[T], pre preimage[T], c *counters) (term[T], bool) {
+	for i := len(pre.ops) - 1; i >= 0; i-- {
+		op := pre.ops[i]
+		c.evaluated++
+		value, ok := calculateUnary(st.ar, t.value, op)
+		if !ok || !st.admits(value) {
+			return term[T]{}, false
+		}
+		t = term[T]{value: value, node: &Node{Op: op, Value: st.ar.Float(value), Left: t.node}}
+	}
+	return t, true
+}
+
+// leafForms returns every way of replacing each of terms by itself or one
+// of its unary forms, terms itself first.
+func (st *searchState[T]) leafForms(terms []term[T]) [][]term[T] {
+	result := [][]term[T]{nil}
+	for _, t := range terms {
+		forms := []term[T]{t}
+		st.eachUnary(t, &st.counts, func(u term[T]) error {
+			forms = append(forms, u)
+			return nil
+		})
+		var next [][]term[T]
+		for _, prefix := range result {
+			for _, form := range forms {
+				next = append(next, append(slices.Clone(prefix), form))
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+// addUnary adds to r the unary forms of the values it holds.
+func (st *searchState[T]) addUnary(r *reachable[T], c *counters) {
+	if !st.s.hasUnary() {
+		return
+	}
+	for _, t := range r.terms {
+		st.eachUnary(t, c, func(u term[T]) error {
+			r.add(st.ar, u)
+			return nil
+		})
+	}
+}
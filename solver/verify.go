@@ -0,0 +1,173 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/x0root/24Solver/expr"
+)
+
+// ErrWrongNumbers is returned by Verify when an answer does not use each
+// number of the hand exactly once.
+var ErrWrongNumbers = errors.New("answer must use each number of the hand exactly once")
+
+// ErrUnevenDivision is returned by Verify, with WithIntegerDivision, when
+// an answer has an intermediate value that is not a whole number.
+var ErrUnevenDivision = errors.New("answer must only divide evenly, with no fractions along the way")
+
+// ErrNegativeValue is returned by Verify, with WithNonNegative, when an
+// answer has an intermediate value below zero.
+var ErrNegativeValue = errors.New("answer must not go below zero along the way")
+
+// ErrWrongResult is returned by Verify when an answer is well formed but
+// does not evaluate to the target.
+type ErrWrongResult struct {
+	Value     float64 // what the answer evaluates to
+	Target    float64
+	Tolerance float64 // see WithTolerance
+}
+
+// ErrTooLarge is returned by Verify, with WithMaxIntermediate, when an
+// answer has an intermediate value beyond the cap.
+type ErrTooLarge struct {
+	Value float64 // the largest intermediate value in magnitude
+	Max   float64
+}
+
+func (e *ErrTooLarge) Error() string {
+	return e.Localize(fmt.Sprintf)
+}
+
+// Localize is ErrHandSize.Localize for e.
+func (e *ErrTooLarge) Localize(sprintf func(format string, args ...any) string) string {
+	return sprintf("answer reaches %g along the way, beyond the cap of %g", e.Value, e.Max)
+}
+
+func (e *ErrWrongResult) Error() string {
+	return e.Localize(fmt.Sprintf)
+}
+
+// Localize is ErrHandSize.Localize for e.
+func (e *ErrWrongResult) Localize(sprintf func(format string, args ...any) string) string {
+	if e.Tolerance > 0 {
+		return sprintf("answer evaluates to %g, not within %g of %g", e.Value, e.Tolerance, e.Target)
+	}
+	return sprintf("answer evaluates to %g, not %g", e.Value, e.Target)
+}
+
+// Verify checks a user-written answer such as "8/(3-8/3)" for the hand nums
+// against the classic rules. See Solver.Verify.
+func Verify(answer string, nums []float64) error {
+	return New().Verify(answer, nums)
+}
+
+// Verify checks that answer is a valid solution for nums: it must parse,
+// use each number of nums exactly once, or as often as WithSubsets and
+// WithReuse allow, joining adjacent digits only with WithConcatenation, use
+// only the configured operators, and evaluate to the target. It returns nil
+// for a correct answer, and otherwise a parse error, ErrWrongNumbers,
+// ErrUnevenDivision, ErrNegativeValue, *ErrTooLarge, *ErrWrongResult or an
+// error naming a disallowed operator.
+func (s *Solver) Verify(answer string, nums []float64) error {
+	if err := s.validateNumbers(nums); err != nil {
+		return err
+	}
+	tree, err := expr.Parse(answer)
+	if err != nil {
+		return fmt.Errorf("invalid answer: %w", err)
+	}
+	if err := s.checkOperators(tree); err != nil {
+		return err
+	}
+	if s.negation {
+		tree = negateLeaves(tree, nums)
+	}
+
+	used := numbersUsed(tree, nums)
+	slices.Sort(used)
+	if !s.matchesHand(used, nums) {
+		return ErrWrongNumbers
+	}
+
+	value, err := tree.Eval()
+	if err != nil {
+		return fmt.Errorf("invalid answer: %w", err)
+	}
+	meta := analyze(tree)
+	if s.integerDivision && meta.Fractional {
+		return ErrUnevenDivision
+	}
+	if s.nonNegative && meta.Negative {
+		return ErrNegativeValue
+	}
+	if s.maxIntermediate > 0 && meta.MaxIntermediate > s.maxIntermediate {
+		return &ErrTooLarge{Value: meta.MaxIntermediate, Max: s.maxIntermediate}
+	}
+	if s.tolerance > 0 {
+		if !isApproximately(value, s.target, s.tolerance+defaultEpsilon) {
+			return &ErrWrongResult{Value: value, Target: s.target, Tolerance: s.tolerance}
+		}
+		return nil
+	}
+	if !s.engine.reaches(tree, s.target) {
+		return &ErrWrongResult{Value: value, Target: s.target}
+	}
+	return nil
+}
+
+// negateLeaves rewrites every negative number of tree that is not in nums,
+// but whose opposite is, as the negation of that number: with WithNegation,
+// -4 is how -(4) is written.
+func negateLeaves(tree *Node, nums []float64) *Node {
+	switch {
+	case tree.IsLeaf():
+		if tree.Value < 0 && !slices.Contains(nums, tree.Value) && slices.Contains(nums, -tree.Value) {
+			return &Node{Op: "neg", Value: tree.Value, Left: &Node{Value: -tree.Value}}
+		}
+	case tree.IsUnary():
+		tree.Left = negateLeaves(tree.Left, nums)
+	default:
+		tree.Left = negateLeaves(tree.Left, nums)
+		tree.Right = negateLeaves(tree.Right, nums)
+	}
+	return tree
+}
+
+// numbersUsed returns the numbers of tree from left to right. A division
+// of two whole numbers that makes a fraction of nums, e.g. 1/3, counts as
+// that one number, since that is how fractions are written.
+func numbersUsed(tree *Node, nums []float64) []float64 {
+	if tree.IsLeaf() {
+		return []float64{tree.Value}
+	}
+	if tree.IsUnary() {
+		return numbersUsed(tree.Left, nums)
+	}
+	l, r := tree.Left, tree.Right
+	if tree.Op == "/" && l.IsLeaf() && r.IsLeaf() && l.Value == math.Trunc(l.Value) && r.Value == math.Trunc(r.Value) && r.Value != 0 {
+		if v := l.Value / r.Value; v != math.Trunc(v) && slices.Contains(nums, v) {
+			return []float64{v}
+		}
+	}
+	return append(numbersUsed(l, nums), numbersUsed(r, nums)...)
+}
+
+// checkOperators reports an error if the tree uses an operator the solver
+// was not configured with.
+func (s *Solver) checkOperators(node *Node) error {
+	if node.IsLeaf() {
+		return nil
+	}
+	if !s.allows(node.Op) {
+		return fmt.Errorf("operator '%s' is not allowed", node.Op)
+	}
+	if err := s.checkOperators(node.Left); err != nil {
+		return err
+	}
+	if node.IsUnary() {
+		return nil
+	}
+	return s.checkOperators(node.Right)
+}
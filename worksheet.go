@@ -0,0 +1,201 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	htmltemplate "html/template"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/x0root/24Solver/solver"
+)
+
+// worksheetPuzzle is one puzzle of a worksheet, with the most elegant of
+// its solutions for the answer key.
+type worksheetPuzzle struct {
+	Hand   string
+	Answer string
+}
+
+// worksheet is what worksheetTemplate renders.
+type worksheet struct {
+	Target     string
+	Difficulty solver.Difficulty
+	Seed       uint64
+	Columns    int
+	Puzzles    []worksheetPuzzle
+}
+
+// worksheetTemplate is the HTML page of a worksheet: the puzzles in a grid
+// with room to write each answer, then the answer key on a page of its
+// own. It needs no stylesheet or script beyond its own, so it can be
+// printed from any browser.
+var worksheetTemplate = htmltemplate.Must(htmltemplate.New("worksheet").Funcs(htmltemplate.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Make {{.Target}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+h1 { margin-bottom: 0.2em; }
+.about { color: #555; margin-top: 0; }
+.name { margin: 1.5em 0; }
+.puzzles { display: grid; grid-template-columns: repeat({{.Columns}}, 1fr); gap: 1.2em; }
+.puzzle { border: 1px solid #999; border-radius: 6px; padding: 0.8em; break-inside: avoid; }
+.hand { font-size: 1.6em; font-weight: bold; letter-spacing: 0.1em; }
+.work { border-bottom: 1px solid #999; height: 3em; }
+.key { break-before: page; page-break-before: always; }
+.key li { margin: 0.3em 0; }
+</style>
+</head>
+<body>
+<h1>Make {{.Target}}</h1>
+<p class="about">Use every number once to make {{.Target}}.{{with .Difficulty}} Difficulty: {{.}}.{{end}}</p>
+<p class="name">Name: ______________________ Date: ____________</p>
+<div class="puzzles">
+{{- range $i, $p := .Puzzles}}
+<div class="puzzle"><div>{{inc $i}}.</div><div class="hand">{{$p.Hand}}</div><div class="work"></div></div>
+{{- end}}
+</div>
+<div class="key">
+<h1>Answer key</h1>
+<p class="about">One solution for each puzzle; there may be others. Seed {{.Seed}}.</p>
+<ol>
+{{- range .Puzzles}}
+<li>{{.Hand}}: {{.Answer}} = {{$.Target}}</li>
+{{- end}}
+</ol>
+</div>
+</body>
+</html>
+`))
+
+// runWorksheet handles the worksheet command: a printable HTML page of
+// puzzles dealt as gen deals them, at most one of each hand, with an answer
+// key on a page of its own, written to stdout or to -o. A -o ending in .pdf
+// is rendered with wkhtmltopdf. The hand size follows -count and the rules
+// the other main flags.
+func runWorksheet(opts []solver.Option, args []string) int {
+	fs := flag.NewFlagSet("worksheet", flag.ContinueOnError)
+	n := fs.Int("n", 20, "how many puzzles to deal")
+	difficulty := fs.String("difficulty", "", "only deal hands that are `easy`, medium or hard (default any solvable hand)")
+	target := fs.Float64("target", 0, "the value the puzzles must make (default the main -target)")
+	columns := fs.Int("columns", 4, "how many puzzles to print side by side, from 1 to 8")
+	seed := fs.Uint64("seed", 0, "seed for the deals, printed on the answer key so a worksheet can be printed again (0 picks one)")
+	out := fs.String("o", "", "write the worksheet to `FILE`, as a PDF if it ends in .pdf (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	want, err := parseWantedDifficulty(*difficulty)
+	if err != nil {
+		errorf("%s", err)
+		return 2
+	}
+	if *n < 1 {
+		errorf("-n must be at least 1, not %d", *n)
+		return 2
+	}
+	if *columns < 1 || *columns > 8 {
+		errorf("-columns must be from 1 to 8, not %d", *columns)
+		return 2
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "target" {
+			opts = append(opts, solver.WithTarget(*target))
+		}
+	})
+	if *seed == 0 {
+		*seed = rand.Uint64()
+	}
+
+	slv := solver.New(opts...)
+	sheet := worksheet{Target: formatNumber(slv.Target()), Difficulty: want, Seed: *seed, Columns: *columns}
+	if sheet.Puzzles, err = dealWorksheet(slv, newRand(*seed), want, *n); err != nil {
+		errorf("%s", err)
+		return 1
+	}
+	if *out == "" {
+		if err := worksheetTemplate.Execute(os.Stdout, sheet); err != nil {
+			errorf("%s", err)
+			return 1
+		}
+		return 0
+	}
+	if err := writeWorksheet(*out, sheet); err != nil {
+		errorf("%s", err)
+		return 1
+	}
+	if !*quiet {
+		printf("Wrote a worksheet of %d puzzle(s) to %s\n", len(sheet.Puzzles), *out)
+	}
+	return 0
+}
+
+// dealWorksheet deals n different hands as deal does, each sorted and with
+// its most elegant solution.
+func dealWorksheet(slv *solver.Solver, rng *rand.Rand, want solver.Difficulty, n int) ([]worksheetPuzzle, error) {
+	var puzzles []worksheetPuzzle
+	seen := make(map[string]bool)
+	for range maxDeals {
+		if len(puzzles) == n {
+			break
+		}
+		nums, solutions, _, err := deal(slv, rng, want)
+		if err != nil {
+			return nil, err
+		}
+		slices.Sort(nums)
+		hand := formatNumbers(nums)
+		if seen[hand] {
+			continue
+		}
+		seen[hand] = true
+		solver.Sort(solutions, solver.SortElegance)
+		puzzles = append(puzzles, worksheetPuzzle{Hand: hand, Answer: displayFormula(solutions[0].Formula)})
+	}
+	if len(puzzles) < n {
+		return nil, newError("only %d different hands found for %d puzzles", len(puzzles), n)
+	}
+	return puzzles, nil
+}
+
+// writeWorksheet writes sheet to path as HTML or, for a .pdf path, as the
+// PDF wkhtmltopdf prints the HTML to.
+func writeWorksheet(path string, sheet worksheet) error {
+	if !strings.EqualFold(filepath.Ext(path), ".pdf") {
+		return writeWorksheetFile(path, sheet)
+	}
+	html := filepath.Join(os.TempDir(), fmt.Sprintf("24solver-worksheet-%d.html", sheet.Seed))
+	if err := writeWorksheetFile(html, sheet); err != nil {
+		return err
+	}
+	defer os.Remove(html)
+	cmd := exec.Command("wkhtmltopdf", "--quiet", html, path)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return errors.New("cannot write a PDF: wkhtmltopdf was not found; install it, or write the worksheet as .html and print it from a browser")
+		}
+		return fmt.Errorf("wkhtmltopdf %s: %v: %s", path, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// writeWorksheetFile writes sheet as HTML to the file at path.
+func writeWorksheetFile(path string, sheet worksheet) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := worksheetTemplate.Execute(f, sheet); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}